@@ -55,7 +55,7 @@ Example:
 			},
 		}
 		
-		fmt.Println("Browsing repositories with the daiv-plugin topic...\n")
+		fmt.Print("Browsing repositories with the daiv-plugin topic...\n\n")
 		
 		result, _, err := client.Search.Repositories(ctx, searchQuery, searchOpts)
 		if err != nil {
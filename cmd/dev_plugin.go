@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"daiv/internal/plugin"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	plug "github.com/iures/daivplug"
+	"github.com/spf13/cobra"
+)
+
+var devPluginCmd = &cobra.Command{
+	Use:   "dev [plugin-dir]",
+	Short: "Build and run a plugin from source, rebuilding when it changes",
+	Long: `Build the plugin in a source directory, run its standup context for a time
+range and print the result. Whenever a source file changes, the plugin is
+rebuilt and run again.
+
+The plugin runs out-of-process, so it can be rebuilt without restarting daiv
+and doesn't have to match daiv's Go toolchain or dependency versions. Its
+main package must not define func main.
+
+Example:
+  daiv plugin dev ./daiv-myplugin
+  daiv plugin dev ./daiv-myplugin --from-time 2025-01-06T00:00:00Z --once`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		fromTime, _ := cmd.Flags().GetString("from-time")
+		toTime, _ := cmd.Flags().GetString("to-time")
+		once, _ := cmd.Flags().GetBool("once")
+		interval, _ := cmd.Flags().GetDuration("interval")
+
+		var timeRange plug.TimeRange
+		if timeRange.Start, err = time.Parse(time.RFC3339, fromTime); err != nil {
+			return fmt.Errorf("invalid from-time format. Must be RFC3339 format: %w", err)
+		}
+		if timeRange.End, err = time.Parse(time.RFC3339, toTime); err != nil {
+			return fmt.Errorf("invalid to-time format. Must be RFC3339 format: %w", err)
+		}
+
+		binDir, err := os.MkdirTemp("", "daivplug-dev-bin-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(binDir)
+		binary := filepath.Join(binDir, filepath.Base(dir))
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		lastFingerprint := ""
+		for {
+			fingerprint, err := plugin.SourceFingerprint(dir)
+			if err != nil {
+				return fmt.Errorf("failed to read plugin sources: %w", err)
+			}
+
+			if fingerprint != lastFingerprint {
+				lastFingerprint = fingerprint
+
+				err := runDevPlugin(dir, binary, timeRange)
+				if once {
+					return err
+				}
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+				fmt.Printf("\nWatching %s for changes, press Ctrl+C to stop\n", dir)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	},
+}
+
+// runDevPlugin builds the plugin, runs its standup context and shuts it down again
+func runDevPlugin(dir string, binary string, timeRange plug.TimeRange) error {
+	fmt.Printf("Building %s\n", dir)
+	start := time.Now()
+	if err := plugin.BuildDevPlugin(dir, binary); err != nil {
+		return err
+	}
+	fmt.Printf("Built in %s\n", time.Since(start).Round(time.Millisecond))
+
+	p, err := plugin.OpenProcessPlugin(binary)
+	if err != nil {
+		return err
+	}
+	defer p.Shutdown()
+
+	// Prompts for missing settings, like when the plugin is installed
+	start = time.Now()
+	if err := plugin.Initialize(p); err != nil {
+		return fmt.Errorf("failed to initialize plugin %s: %w", p.Name(), err)
+	}
+	fmt.Printf("Initialized %s in %s\n", p.Name(), time.Since(start).Round(time.Millisecond))
+
+	standupPlugin, ok := p.(plug.StandupPlugin)
+	if !ok || !slices.Contains(plugin.Capabilities(p), plugin.CapabilityStandup) {
+		return fmt.Errorf("plugin %s does not implement StandupPlugin", p.Name())
+	}
+
+	start = time.Now()
+	standupContext, err := standupPlugin.GetStandupContext(timeRange)
+	if err != nil {
+		return fmt.Errorf("GetStandupContext failed: %w", err)
+	}
+	fmt.Printf("GetStandupContext(%s - %s) took %s\n\n",
+		timeRange.Start.Format(time.RFC3339), timeRange.End.Format(time.RFC3339), time.Since(start).Round(time.Millisecond))
+
+	if standupContext.Content == "" {
+		fmt.Println("(empty standup context)")
+	} else {
+		fmt.Println(standupContext.Content)
+	}
+
+	return nil
+}
+
+func init() {
+	devPluginCmd.Flags().String("from-time", time.Now().AddDate(0, 0, -1).Truncate(24*time.Hour).Format(time.RFC3339), "Start time for the standup context (RFC3339 format)")
+	devPluginCmd.Flags().String("to-time", time.Now().Truncate(24*time.Hour).Add(24*time.Hour-time.Nanosecond).Format(time.RFC3339), "End time for the standup context (RFC3339 format)")
+	devPluginCmd.Flags().Bool("once", false, "Build and run the plugin once instead of watching for changes")
+	devPluginCmd.Flags().Duration("interval", time.Second, "How often to check the sources for changes")
+	pluginCmd.AddCommand(devPluginCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"daiv/internal/plugin"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var disablePluginCmd = &cobra.Command{
+	Use:   "disable [plugin-name]",
+	Short: "Disable a plugin without uninstalling it",
+	Long: `Disable a plugin without uninstalling it. Disabled plugins are not loaded
+and don't contribute to reports until they are enabled again.
+
+Example:
+  daiv plugin disable my-plugin`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if plugin.IsDisabled(name) {
+			fmt.Printf("Plugin %s is already disabled\n", name)
+			return nil
+		}
+
+		if !isKnownPlugin(name) {
+			fmt.Printf("Warning: no installed plugin named %s\n", name)
+		}
+
+		if err := plugin.SetEnabled(name, false); err != nil {
+			return fmt.Errorf("failed to disable plugin: %w", err)
+		}
+
+		fmt.Printf("Disabled plugin: %s\n", name)
+		return nil
+	},
+}
+
+// isKnownPlugin reports whether a plugin is registered or recorded in the lockfile
+func isKnownPlugin(name string) bool {
+	if _, ok := plugin.GetRegistry().Get(name); ok {
+		return true
+	}
+
+	manager, err := newPluginManager()
+	if err != nil {
+		return false
+	}
+
+	lock, err := manager.Lockfile()
+	if err != nil {
+		return false
+	}
+
+	_, ok := lock.Find(name)
+	return ok
+}
+
+func init() {
+	pluginCmd.AddCommand(disablePluginCmd)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"daiv/internal/plugin"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var enablePluginCmd = &cobra.Command{
+	Use:   "enable [plugin-name]",
+	Short: "Enable a previously disabled plugin",
+	Long: `Enable a plugin that was disabled with 'daiv plugin disable'.
+
+Example:
+  daiv plugin enable my-plugin`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if !plugin.IsDisabled(name) {
+			fmt.Printf("Plugin %s is not disabled\n", name)
+			return nil
+		}
+
+		if err := plugin.SetEnabled(name, true); err != nil {
+			return fmt.Errorf("failed to enable plugin: %w", err)
+		}
+
+		fmt.Printf("Enabled plugin: %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(enablePluginCmd)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"daiv/internal/plugin"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var infoPluginCmd = &cobra.Command{
+	Use:   "info [plugin-name]",
+	Short: "Show details about a plugin",
+	Long: `Show details about a plugin: its name and version as reported by the plugin,
+where it was installed from, its config keys and whether they are set, the
+capabilities it provides, and why it failed to load, if it did.
+
+Secret config values are never shown.
+
+Example:
+  daiv plugin info my-plugin`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		manager, err := newPluginManager()
+		if err != nil {
+			return err
+		}
+
+		var info *plugin.PluginInfo
+		if p, ok := plugin.GetRegistry().Get(name); ok {
+			described := plugin.Describe(p)
+			info = &described
+
+			if lock, err := manager.Lockfile(); err == nil {
+				if entry, ok := lock.Find(name); ok {
+					info.Entry = entry
+					info.File = entry.File
+				}
+			}
+		} else {
+			info, err = manager.Inspect(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		printPluginInfo(info)
+		return nil
+	},
+}
+
+func printPluginInfo(info *plugin.PluginInfo) {
+	status := "enabled"
+	switch {
+	case plugin.IsDisabled(info.Name) || (info.Entry != nil && plugin.IsDisabled(info.Entry.Name)):
+		status = "disabled"
+	case info.Quarantined != nil:
+		status = "quarantined"
+	case info.LoadError != nil:
+		status = "failed to load"
+	}
+
+	version := info.Version
+	if version == "" && info.Entry != nil {
+		version = info.Entry.DisplayVersion()
+	}
+
+	fmt.Printf("Name:         %s\n", info.Name)
+	if version != "" {
+		fmt.Printf("Version:      %s\n", version)
+	}
+	fmt.Printf("Status:       %s\n", status)
+
+	if info.Entry != nil {
+		fmt.Printf("Source:       %s\n", info.Entry.Source)
+		fmt.Printf("Installed:    %s\n", info.Entry.InstalledAt.Format("2006-01-02 15:04"))
+		fmt.Printf("Checksum:     %s\n", info.Entry.Checksum)
+		if info.Entry.SignedBy != "" {
+			fmt.Printf("Signed by:    %s\n", info.Entry.SignedBy)
+		}
+		if info.Entry.Build != nil {
+			fmt.Printf("Built with:   %s (%s)\n", info.Entry.Build.GoVersion, info.Entry.Build.Platform)
+		}
+	} else if info.File == "" {
+		fmt.Printf("Source:       built-in\n")
+	}
+	if info.File != "" {
+		fmt.Printf("File:         %s\n", info.File)
+	}
+
+	if info.LoadError != nil {
+		fmt.Printf("Load error:   %v\n", info.LoadError)
+		return
+	}
+
+	capabilities := "none"
+	if len(info.Capabilities) > 0 {
+		capabilities = strings.Join(info.Capabilities, ", ")
+	}
+	fmt.Printf("Capabilities: %s\n", capabilities)
+	if len(info.Dependencies) > 0 {
+		fmt.Printf("Depends on:   %s\n", strings.Join(info.Dependencies, ", "))
+	}
+	if info.Priority != 0 {
+		fmt.Printf("Priority:     %d\n", info.Priority)
+	}
+
+	if len(info.ConfigKeys) == 0 {
+		fmt.Printf("Config keys:  none\n")
+		return
+	}
+
+	fmt.Printf("Config keys:\n")
+	for _, key := range info.ConfigKeys {
+		var attributes []string
+		if key.Name != "" {
+			attributes = append(attributes, key.Name)
+		}
+		if key.Required {
+			attributes = append(attributes, "required")
+		}
+		if key.Secret {
+			attributes = append(attributes, "secret")
+		}
+		if key.EnvVar != "" {
+			attributes = append(attributes, "$"+key.EnvVar)
+		}
+
+		value := "not set"
+		if key.IsSet {
+			value = "set"
+			if key.Display != "" {
+				value = key.Display
+			}
+		}
+
+		fmt.Printf("  - %s (%s): %s\n", key.Key, strings.Join(attributes, ", "), value)
+	}
+}
+
+func init() {
+	pluginCmd.AddCommand(infoPluginCmd)
+}
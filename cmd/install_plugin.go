@@ -10,15 +10,24 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var installPluginChecksum string
+
 var installPluginCmd = &cobra.Command{
-	Use:     "install [github-repo or url or local-file] [version]",
+	Use:     "install [github-repo or git-url or url or local-path] [version]",
 	Aliases: []string{"add"},
-	Short:   "Install a plugin from a GitHub repository, URL, or local file",
-	Long:    `Install a plugin from a GitHub repository, direct URL, or local file.
+	Short:   "Install a plugin from a GitHub repository, git URL, URL, or local path",
+	Long:    `Install a plugin from a GitHub repository, git URL, direct URL, or local path.
 
 For GitHub repositories:
   daiv plugin install username/repo-name [version]
 
+For other git repositories, including ssh and self-hosted hosts:
+  daiv plugin install git@git.example.com:team/daiv-plugin.git [version]
+  daiv plugin install https://git.example.com/team/daiv-plugin.git [version]
+
+Plugins from git repositories and local source directories are built with the
+same Go toolchain and dependency versions as daiv.
+
 For direct URLs:
   daiv plugin install https://example.com/path/to/plugin.so
 
@@ -26,10 +35,22 @@ For local files:
   daiv plugin install /path/to/plugin.so
   daiv plugin install ./plugin.so
 
+For local plugin source directories:
+  daiv plugin install ./daiv-myplugin
+
+Local executables are installed as out-of-process plugins:
+  daiv plugin install ./out/daiv-myplugin
+
+Downloads and local files are verified before they are installed: against
+--sha256 when given, against a .sha256 file published next to the plugin,
+and against a .minisig signature using the keys in plugins.trustedKeys.
+Set plugins.requireSignature to reject unsigned plugins.
+
 Example:
   daiv plugin install username/daiv-worklog-plugin
   daiv plugin install username/daiv-worklog-plugin v1.0.0
   daiv plugin install https://example.com/plugins/worklog-plugin.so
+  daiv plugin install https://example.com/plugins/worklog-plugin.so --sha256 <checksum>
   daiv plugin install ./my-plugin.so`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -51,23 +72,51 @@ Example:
 			return fmt.Errorf("failed to create plugin manager: %w", err)
 		}
 
+		// Check if source is a git repository outside of GitHub
+		if plugin.IsGitURL(source) {
+			if installPluginChecksum != "" {
+				return fmt.Errorf("--sha256 is only supported for URLs and local files, git plugins are built from source")
+			}
+			
+			fmt.Printf("Installing plugin from git: %s\n", source)
+			if err := manager.InstallFromGit(source, version); err != nil {
+				return fmt.Errorf("failed to install plugin from git: %w", err)
+			}
+			fmt.Printf("Successfully installed plugin from %s\n", source)
+			return nil
+		}
+		
 		// Check if source is a URL
 		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
 			// Install from direct URL
 			fmt.Printf("Installing plugin from URL: %s\n", source)
-			if err := manager.InstallFromURL(source); err != nil {
+			if err := manager.InstallFromURL(source, installPluginChecksum); err != nil {
 				return fmt.Errorf("failed to install plugin from URL: %w", err)
 			}
 			fmt.Printf("Successfully installed plugin from %s\n", source)
 			return nil
 		}
 		
-		// Check if source is a local file
+		// Check if source is a local plugin source directory
 		fileInfo, err := os.Stat(source)
+		if err == nil && fileInfo.IsDir() {
+			if installPluginChecksum != "" {
+				return fmt.Errorf("--sha256 is only supported for URLs and local files, plugin directories are built from source")
+			}
+			
+			fmt.Printf("Installing plugin from source directory: %s\n", source)
+			if err := manager.InstallFromSourceDir(source); err != nil {
+				return fmt.Errorf("failed to install plugin from source directory: %w", err)
+			}
+			fmt.Printf("Successfully installed plugin from %s\n", source)
+			return nil
+		}
+		
+		// Check if source is a local file
 		if err == nil && !fileInfo.IsDir() {
 			// Install from local file
 			fmt.Printf("Installing plugin from local file: %s\n", source)
-			if err := manager.InstallFromLocalFile(source); err != nil {
+			if err := manager.InstallFromLocalFile(source, installPluginChecksum); err != nil {
 				return fmt.Errorf("failed to install plugin from local file: %w", err)
 			}
 			fmt.Printf("Successfully installed plugin from %s\n", source)
@@ -75,6 +124,10 @@ Example:
 		}
 		
 		// If we get here, assume it's a GitHub repository
+		if installPluginChecksum != "" {
+			return fmt.Errorf("--sha256 is only supported for URLs and local files, GitHub plugins are built from source")
+		}
+		
 		fmt.Printf("Installing plugin from GitHub: %s", source)
 		if version != "" {
 			fmt.Printf(" (version: %s)", version)
@@ -96,5 +149,6 @@ Example:
 }
 
 func init() {
+	installPluginCmd.Flags().StringVar(&installPluginChecksum, "sha256", "", "Expected sha256 checksum of the plugin file")
 	pluginCmd.AddCommand(installPluginCmd)
 } 
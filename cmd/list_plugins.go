@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var listPluginsCmd = &cobra.Command{
@@ -22,10 +23,7 @@ Example:
 		registry := plugin.GetRegistry()
 		
 		// Get all registered plugins
-		builtInPlugins := []string{}
-		for name := range registry.Plugins {
-			builtInPlugins = append(builtInPlugins, name)
-		}
+		builtInPlugins := registry.Names()
 		
 		// Get plugins directory
 		homeDir, err := os.UserHomeDir()
@@ -34,28 +32,78 @@ Example:
 		}
 		
 		pluginsDir := filepath.Join(homeDir, ".daiv", "plugins")
+		manager, err := plugin.NewPluginManager(pluginsDir)
+		if err != nil {
+			return fmt.Errorf("failed to create plugin manager: %w", err)
+		}
+		
+		// External plugins come from the lockfile
+		lock, err := manager.Lockfile()
+		if err != nil {
+			return err
+		}
+		
+		quarantined, err := manager.Quarantined()
+		if err != nil {
+			return err
+		}
+		
+		isQuarantined := map[string]bool{}
+		for _, q := range quarantined {
+			isQuarantined[q.File] = true
+		}
 		
-		// Check if external plugins directory exists
 		externalPlugins := []string{}
-		if _, err := os.Stat(pluginsDir); !os.IsNotExist(err) {
-			// Read directory
-			entries, err := os.ReadDir(pluginsDir)
-			if err != nil {
-				return fmt.Errorf("failed to read plugins directory: %w", err)
+		for _, entry := range lock.Plugins {
+			description := entry.Name
+			if version := entry.DisplayVersion(); version != "" {
+				description += " " + version
+			}
+			description += fmt.Sprintf(" (%s, installed %s)", entry.Source, entry.InstalledAt.Format("2006-01-02"))
+			if plugin.IsDisabled(entry.Name) {
+				description += " [disabled]"
+			}
+			if isQuarantined[entry.File] {
+				description += " [quarantined]"
+			} else if _, err := os.Stat(filepath.Join(pluginsDir, entry.File)); err != nil {
+				description += " [missing " + entry.File + "]"
+			}
+			externalPlugins = append(externalPlugins, description)
+		}
+		
+		// Plugin files copied into the plugins directory by hand are not in the lockfile
+		entries, err := os.ReadDir(pluginsDir)
+		if err != nil {
+			return fmt.Errorf("failed to read plugins directory: %w", err)
+		}
+		
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			
+			filename := entry.Name()
+			if strings.HasPrefix(filename, ".") {
+				continue
+			}
+			if _, ok := lock.FindFile(filename); ok {
+				continue
 			}
 			
-			// Collect plugin filenames
-			for _, entry := range entries {
-				if entry.IsDir() {
-					continue
-				}
-				
-				filename := entry.Name()
-				if ext := filepath.Ext(filename); ext == ".so" || ext == ".dll" {
-					// Remove extension from filename
-					pluginName := strings.TrimSuffix(filename, ext)
-					externalPlugins = append(externalPlugins, pluginName)
-				}
+			if ext := filepath.Ext(filename); ext == ".so" || ext == ".dll" {
+				// Remove extension from filename
+				pluginName := strings.TrimSuffix(filename, ext)
+				externalPlugins = append(externalPlugins, pluginName+" (not recorded in "+plugin.LockfileName+")")
+			} else if info, err := entry.Info(); err == nil && plugin.IsProcessPlugin(filepath.Join(pluginsDir, filename), info) {
+				pluginName := strings.TrimSuffix(filename, ".exe")
+				externalPlugins = append(externalPlugins, pluginName+" (out-of-process, not recorded in "+plugin.LockfileName+")")
+			}
+		}
+		
+		// Disabled plugins aren't registered, list the ones not recorded in the lockfile
+		for _, name := range viper.GetStringSlice(plugin.DisabledPluginsKey) {
+			if _, ok := lock.Find(name); !ok {
+				externalPlugins = append(externalPlugins, name+" [disabled]")
 			}
 		}
 		
@@ -79,6 +127,14 @@ Example:
 			}
 		}
 		
+		// Print plugins that failed to load
+		if len(quarantined) > 0 {
+			fmt.Printf("\nQuarantined plugins (in %s, reinstall to restore):\n", filepath.Join(pluginsDir, plugin.QuarantineDirName))
+			for _, q := range quarantined {
+				fmt.Printf("  - %s (%s): %s\n", q.File, q.QuarantinedAt.Format("2006-01-02"), q.Reason)
+			}
+		}
+		
 		return nil
 	},
 }
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"daiv/internal/plugin"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 )
 
@@ -12,18 +17,43 @@ var pluginCmd = &cobra.Command{
 These commands help you work with daiv plugins:
   - install: Install a plugin from a GitHub repository or URL
   - create: Generate a new empty plugin template
+  - dev: Build and run a plugin from source, rebuilding when it changes
+  - test: Check a plugin against daiv's expectations
   - list: List all installed plugins
+  - info: Show details about a plugin
   - browse: Browse repositories with the daiv-plugin topic
+  - update: Update installed plugins from their recorded source
+  - rollback: Restore the version replaced by the last update
+  - enable: Enable a disabled plugin
+  - disable: Disable a plugin without uninstalling it
   - uninstall: Remove an installed plugin
 
 Example:
   daiv plugin install username/my-plugin
   daiv plugin create my-new-plugin
   daiv plugin list
+  daiv plugin info my-plugin
   daiv plugin browse --filter "git integration"
+  daiv plugin update --all
+  daiv plugin disable my-plugin
   daiv plugin uninstall my-plugin`,
 }
 
+// newPluginManager creates a plugin manager for the default plugins directory
+func newPluginManager() (*plugin.PluginManager, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	manager, err := plugin.NewPluginManager(filepath.Join(homeDir, ".daiv", "plugins"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin manager: %w", err)
+	}
+
+	return manager, nil
+}
+
 func init() {
 	rootCmd.AddCommand(pluginCmd)
 } 
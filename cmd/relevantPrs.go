@@ -2,185 +2,63 @@ package cmd
 
 import (
 	"context"
-	"daiv/internal/llm"
 	"fmt"
 	"log"
+	"os"
+	"slices"
 	"strings"
-	// "sync"
 
-	// internalGithub "daiv/internal/github"
+	"daiv/internal/llm"
+	"daiv/internal/relevantprs"
 
-	"github.com/google/go-github/v68/github"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-// RepositoryConfig holds the configuration for each repository.
-type RepositoryConfig struct {
-	Owner        string   `mapstructure:"owner"`
-	Repo         string   `mapstructure:"repo"`
-	SystemPrompt string   `mapstructure:"system_prompt"`
-	Keywords     []string `mapstructure:"keywords"`
-}
-
-// Config represents our overall configuration for the command.
-type Config struct {
-	Repositories []RepositoryConfig `mapstructure:"repositories"`
-}
-
-// getConfig extracts and validates the configuration for relevantPrs.
-func getConfig() (Config, error) {
-	sub := viper.Sub("relevantPrs")
-	if sub == nil {
-		return Config{}, fmt.Errorf("no configuration found for relevantPrs; please add a 'relevantPrs' section to your config file")
+// relevantPrs is the main function for the command, orchestrating configuration reading,
+// and concurrent processing of the repositories.
+func relevantPrs(format string, noLLM bool, score bool) {
+	cfg, err := relevantprs.LoadConfig()
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
 	}
 
-	var cfg Config
-	if err := sub.Unmarshal(&cfg); err != nil {
-		return Config{}, fmt.Errorf("error unmarshaling relevantPrs config: %w", err)
-	}
+	ctx := context.Background()
 
-	if len(cfg.Repositories) == 0 {
-		return Config{}, fmt.Errorf("no repositories configured")
+	report := relevantprs.Report{
+		Results:   relevantprs.ScanRepositories(ctx, cfg.Repositories),
+		Summaries: make(map[string]string),
 	}
 
-	return cfg, nil
-}
-
-// listAllPRs collects all pull requests with pagination support.
-func listAllPRs(ctx context.Context, client *github.Client, owner, repo string, options *github.PullRequestListOptions) ([]*github.PullRequest, error) {
-	var allPRs []*github.PullRequest
-	opts := *options // create a copy so as not to modify the original options
-	opts.ListOptions = github.ListOptions{PerPage: 100}
-
-	for {
-		prs, resp, err := client.PullRequests.List(ctx, owner, repo, &opts)
+	if score {
+		llmClient, err := llm.NewClient()
 		if err != nil {
-			return nil, err
-		}
-		allPRs = append(allPRs, prs...)
-		if resp.NextPage == 0 {
-			break
+			log.Fatalf("Error creating LLM client: %v", err)
 		}
-		opts.Page = resp.NextPage
-	}
 
-	return allPRs, nil
-}
-
-// findKeywordMatches scans the diff text and returns any lines that match one or more keywords (case-insensitive)
-func findKeywordMatches(diffStr string, keywords []string) []string {
-	var matchedLines []string
-	// Precompute lower-case keywords to avoid repetition.
-	lowerKeywords := make([]string, len(keywords))
-	for i, keyword := range keywords {
-		lowerKeywords[i] = strings.ToLower(keyword)
-	}
-
-	for _, line := range strings.Split(diffStr, "\n") {
-		lowerLine := strings.ToLower(line)
-		for _, lowerKeyword := range lowerKeywords {
-			if strings.Contains(lowerLine, lowerKeyword) {
-				matchedLines = append(matchedLines, line)
-				break
-			}
+		for i := range report.Results {
+			relevantprs.ScoreResult(llmClient, &report.Results[i])
 		}
-	}
-
-	return matchedLines
-}
-
-// processRepository handles querying a single repository and outputting the matching PR changes.
-func processRepository(ctx context.Context, client *github.Client, repoConfig RepositoryConfig) {
-	prList, err := listAllPRs(ctx, client, repoConfig.Owner, repoConfig.Repo, &github.PullRequestListOptions{
-		State: "open",
-	})
-	if err != nil {
-		log.Printf("Error listing PRs for %s/%s: %v", repoConfig.Owner, repoConfig.Repo, err)
-		return
-	}
-
-	var report strings.Builder
-	hasMatchedLines := false
-
-	for index, pr := range prList {
-		diff, _, err := client.PullRequests.GetRaw(ctx, repoConfig.Owner, repoConfig.Repo, pr.GetNumber(), github.RawOptions{Type: github.Diff})
-		if err != nil {
-			log.Printf("Error getting diff for PR #%d: %v", pr.GetNumber(), err)
-			continue
-		}
-
-		diffStr := string(diff)
-
-		matchedLines := findKeywordMatches(diffStr, repoConfig.Keywords)
-
-		if len(matchedLines) > 0 {
-			hasMatchedLines = true
-
-			if index == 0 {
-				fmt.Fprintf(&report, "Repository: %s/%s\n", repoConfig.Owner, repoConfig.Repo)
+	} else if !noLLM {
+		for _, result := range report.Results {
+			if len(result.PullRequests) == 0 {
+				continue
 			}
 
-			fmt.Fprintf(&report, "  (PR #%d)[%s]: \n  %s\n", pr.GetNumber(), pr.GetHTMLURL(), pr.GetTitle())
-
-			fmt.Fprintln(&report, "    Matched changes:")
-			for _, mLine := range matchedLines {
-				fmt.Fprintf(&report, "      %s\n", mLine)
+			completion, err := relevantprs.Summarize(result)
+			if err != nil {
+				log.Printf("Error summarizing %s: %v", result.Repository.FullName(), err)
+				continue
 			}
-		}
-	}
-
-	fmt.Fprintln(&report, "")
 
-	if !hasMatchedLines {
-		return
-	}
-
-	llmClient, err := llm.NewClient()
-	if err != nil {
-		fmt.Printf("Error creating LLM client: %v", err)
+			report.Summaries[result.Repository.FullName()] = completion
+		}
 	}
 
-	var prompt strings.Builder
-
-	fmt.Fprintf(&prompt, "System prompt: %s\n %s", repoConfig.SystemPrompt, report.String())
-
-	completion, err := llmClient.GenerateFromSinglePrompt(prompt.String())
-	if err != nil {
-		fmt.Printf("Error generating completion: %v", err)
+	if err := relevantprs.WriteReport(os.Stdout, format, report); err != nil {
+		log.Fatalf("Error writing report: %v", err)
 	}
-
-	fmt.Println(completion)
-}
-
-// relevantPrs is the main function for the command, orchestrating configuration reading,
-// GitHub client creation, and concurrent processing of the repositories.
-func relevantPrs() {
-  // FIXME: This function is currently disabled, re-enable it before merging
-  //
-	// cfg, err := getConfig()
-	// if err != nil {
-	// 	log.Fatalf("Configuration error: %v", err)
-	// }
-	//
-	// ctx := context.Background()
-	// client, err := internalGithub.NewGithubClient()
-	// if err != nil {
-	// 	log.Fatalf("Error creating github client: %v", err)
-	// }
-	//
-	// var wg sync.WaitGroup
-	// for _, repoConfig := range cfg.Repositories {
-	// 	wg.Add(1)
-	// 	// Capture the variable to avoid race conditions.
-	// 	repoCfg := repoConfig
-	// 	go func() {
-	// 		defer wg.Done()
-	// 		processRepository(ctx, client, repoCfg)
-	// 	}()
-	// }
-	// wg.Wait()
 }
 
 // relevantPrsCmd represents the updated relevantPrs command with improved descriptions.
@@ -189,8 +67,35 @@ var relevantPrsCmd = &cobra.Command{
 	Short: "Search open PRs for changes matching specific keywords",
 	Long: `Searches through all open pull requests in specified repositories
 and displays changes containing user-defined keywords. This helps in quickly
-identifying the relevant code changes among many open PRs.`,
-	Run: func(cmd *cobra.Command, args []string) {
+identifying the relevant code changes among many open PRs.
+
+By default the matches of each repository are summarized by the LLM using the
+repository's system prompt. Use --format to get the raw matches (repository,
+PR, file, line and keyword) as json, markdown or a table, with the LLM summary
+as an extra section, and --no-llm to skip the summary entirely.
+
+With --score the LLM rates each matched PR from 0 to 10 with a one-line reason
+instead of summarizing the repository. PRs are ranked by score and the ones
+below the repository's min_score are hidden. PRs the LLM failed to score are
+listed after the scored ones, marked as not scored.
+
+Example:
+  daiv relevantPrs
+  daiv relevantPrs --format json --no-llm
+  daiv relevantPrs --format markdown
+  daiv relevantPrs --score --format table`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if !slices.Contains(relevantprs.Formats, format) {
+			return fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(relevantprs.Formats, ", "))
+		}
+		noLLM, _ := cmd.Flags().GetBool("no-llm")
+		score, _ := cmd.Flags().GetBool("score")
+		if noLLM && score {
+			return fmt.Errorf("--score requires the LLM and cannot be combined with --no-llm")
+		}
+
 		var bar *progressbar.ProgressBar
 		if !viper.GetBool("no-progress") {
 			bar = progressbar.Default(
@@ -199,14 +104,20 @@ identifying the relevant code changes among many open PRs.`,
 			)
 		}
 
-		relevantPrs()
+		relevantPrs(format, noLLM, score)
 
 		if bar != nil {
 			bar.Clear()
 		}
+
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(relevantPrsCmd)
+
+	relevantPrsCmd.Flags().String("format", relevantprs.FormatText, "Output format: "+strings.Join(relevantprs.Formats, ", "))
+	relevantPrsCmd.Flags().Bool("no-llm", false, "Only report the raw matches, without an LLM summary")
+	relevantPrsCmd.Flags().Bool("score", false, "Score and rank each matched PR with the LLM instead of summarizing")
 }
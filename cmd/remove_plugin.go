@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"daiv/internal/plugin"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,29 +35,17 @@ Example:
 			return fmt.Errorf("plugins directory does not exist")
 		}
 		
-		// Check for plugin file with .so extension
-		soPath := filepath.Join(pluginsDir, pluginName+".so")
-		if _, err := os.Stat(soPath); err == nil {
-			// Remove the .so file
-			if err := os.Remove(soPath); err != nil {
-				return fmt.Errorf("failed to remove plugin: %w", err)
-			}
-			fmt.Printf("Successfully removed plugin: %s\n", pluginName)
-			return nil
+		manager, err := plugin.NewPluginManager(pluginsDir)
+		if err != nil {
+			return fmt.Errorf("failed to create plugin manager: %w", err)
 		}
 		
-		// Check for plugin file with .dll extension
-		dllPath := filepath.Join(pluginsDir, pluginName+".dll")
-		if _, err := os.Stat(dllPath); err == nil {
-			// Remove the .dll file
-			if err := os.Remove(dllPath); err != nil {
-				return fmt.Errorf("failed to remove plugin: %w", err)
-			}
-			fmt.Printf("Successfully removed plugin: %s\n", pluginName)
-			return nil
+		if err := manager.Uninstall(pluginName); err != nil {
+			return err
 		}
 		
-		return fmt.Errorf("plugin '%s' not found", pluginName)
+		fmt.Printf("Successfully removed plugin: %s\n", pluginName)
+		return nil
 	},
 }
 
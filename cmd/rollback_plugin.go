@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var rollbackPluginCmd = &cobra.Command{
+	Use:   "rollback [plugin-name]",
+	Short: "Restore the version of a plugin replaced by its last update",
+	Long: `Restore the version of a plugin that was replaced by 'daiv plugin update'.
+
+Example:
+  daiv plugin rollback my-plugin`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newPluginManager()
+		if err != nil {
+			return err
+		}
+
+		if err := manager.Rollback(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Printf("Successfully rolled back plugin: %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(rollbackPluginCmd)
+}
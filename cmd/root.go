@@ -4,14 +4,20 @@ Copyright © 2025 Iure Sales
 package cmd
 
 import (
+	"daiv/internal/localgit"
 	"daiv/internal/plugin"
 	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -21,15 +27,53 @@ var rootCmd = &cobra.Command{
 	Long: `Daiv is a command-line tool designed to streamline developer workflows 
 and enhance team communication. It provides various utilities to help developers 
 be more productive in their daily tasks.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if !runsHooks(cmd) {
+			return nil
+		}
+		loadPlugins()
+		return plugin.GetRegistry().RunBeforeCommand(commandName(cmd), args)
+	},
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	mountPluginCommands(os.Args[1:])
+
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil && runsHooks(cmd) {
+		plugin.GetRegistry().RunOnError(commandName(cmd), err)
+	}
+
+	if err := plugin.GetRegistry().ShutdownAll(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// usesPluginsAnnotation marks the commands that use plugins, such as standup and the
+// plugins' own commands. External plugins are only loaded, and the hooks only run, around
+// these, so that other commands don't start plugins or mix their output with the loader's.
+const usesPluginsAnnotation = "daiv.usesPlugins"
+
+// runsHooks reports whether the plugin hooks run around a command, which is the case for
+// the commands that use plugins.
+func runsHooks(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[usesPluginsAnnotation]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// commandName returns the path of a command below daiv, e.g. "jira transition".
+func commandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
@@ -75,10 +119,30 @@ func init() {
 	viper.BindEnv("github.organization", "GITHUB_ORG")
 	viper.BindEnv("github.repositories", "GITHUB_REPOS")
 	viper.BindEnv("github.username", "GITHUB_USERNAME")
+	viper.BindEnv("github.token", "GITHUB_TOKEN")
+	viper.BindEnv("gitlab.token", "GITLAB_TOKEN")
+	viper.BindEnv("bitbucket.token", "BITBUCKET_TOKEN")
 	viper.BindEnv("worklog.path", "WORKLOG_PATH")
 }
 
+var configOnce sync.Once
+
+// initConfig loads the configuration and registers the built-in plugins, once, either
+// when a command runs or earlier when the plugins' commands have to be mounted.
 func initConfig() {
+	configOnce.Do(loadConfigAndPlugins)
+}
+
+// loadPlugins loads the external plugins for commands that use plugins. They are only
+// initialized when a command uses them. It is a variable so tests can see when it runs.
+var loadPlugins = func() {
+	if err := plugin.GetRegistry().LoadExternalPlugins(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func loadConfigAndPlugins() {
 	viper.AutomaticEnv()
 
 	if err := loadConfigs(); err != nil {
@@ -102,32 +166,137 @@ func initConfig() {
 	registerPlugins()
 }
 
+// registerPlugins adds the built-in plugins. Like external plugins, they are only
+// initialized when a command uses them.
 func registerPlugins() {
 	registry := plugin.GetRegistry()
-	
+
+	if err := registry.Add(localgit.New()); err != nil {
+		slog.Error("Failed to register local git plugin", "error", err)
+		os.Exit(1)
+	}
+
 	// githubPlugin := github.NewGitHubPlugin()
-	// if err := registry.Register(githubPlugin); err != nil {
+	// if err := registry.Add(githubPlugin); err != nil {
 	// 	slog.Error("Failed to register GitHub plugin", "error", err)
 	// 	os.Exit(1)
 	// }
 
 	// jiraPlugin := jira.NewJiraPlugin()
-	// if err := registry.Register(jiraPlugin); err != nil {
+	// if err := registry.Add(jiraPlugin); err != nil {
 	// 	slog.Error("Failed to register Jira plugin", "error", err)
 	// 	os.Exit(1)
 	// }
 
 	// worklogPlugin := worklog.NewWorklogPlugin()
-	// if err := registry.Register(worklogPlugin); err != nil {
+	// if err := registry.Add(worklogPlugin); err != nil {
 	// 	slog.Error("Failed to register Worklog plugin", "error", err)
 	// 	os.Exit(1)
 	// }
-	
-	// Load external plugins
-	if err := registry.LoadExternalPlugins(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+
+	// External plugins are loaded by the commands that use them, see loadPlugins
+}
+
+// mountPluginCommands adds the subcommands of the registered plugins under their names.
+// Cobra resolves the command before running initConfig, so when the first argument names
+// an installed plugin the plugins are loaded up front. Other invocations, such as a
+// built-in or mistyped command, help or no command at all, don't load any plugin. A
+// plugin is only initialized when one of its commands runs.
+func mountPluginCommands(args []string) {
+	// --config has to be known before loading the plugins, the flags are parsed again
+	// once the command is resolved
+	flags := pflag.NewFlagSet("daiv", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.Usage = func() {}
+	// The global flags are copied rather than shared, so their values, such as slices,
+	// aren't set twice
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		flags.StringP(f.Name, f.Shorthand, "", "")
+		flags.Lookup(f.Name).NoOptDefVal = f.NoOptDefVal
+	})
+	flags.BoolP("help", "h", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return
 	}
+
+	// The completion command is added by cobra when it runs
+	command := flags.Arg(0)
+	if command == "help" || command == "completion" || findCommand(command) != nil || !namesInstalledPlugin(command) {
+		return
+	}
+
+	if config, _ := flags.GetString("config"); config != "" {
+		rootCmd.PersistentFlags().Set("config", config)
+	}
+
+	initConfig()
+	loadPlugins()
+
+	for _, p := range plugin.GetRegistry().All() {
+		commandPlugin, ok := p.(plugin.CommandPlugin)
+		if !ok || !slices.Contains(plugin.Capabilities(p), plugin.CapabilityCommands) || !plugin.IsEnabled(p.Name()) {
+			continue
+		}
+
+		name := commandPlugin.Name()
+		if builtin := findCommand(name); builtin != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Plugin %s can't add commands, 'daiv %s' is a built-in command\n", name, name)
+			continue
+		}
+
+		commands := commandPlugin.Commands()
+		if len(commands) == 0 {
+			continue
+		}
+
+		pluginCmd := &cobra.Command{
+			Use:         name,
+			Short:       fmt.Sprintf("Commands provided by the %s plugin", name),
+			Annotations: map[string]string{usesPluginsAnnotation: ""},
+			PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+				if _, err := plugin.GetRegistry().Use(name); err != nil {
+					return err
+				}
+				return rootCmd.PersistentPreRunE(cmd, args)
+			},
+		}
+		pluginCmd.AddCommand(commands...)
+		rootCmd.AddCommand(pluginCmd)
+	}
+}
+
+// namesInstalledPlugin reports whether a command could be the name of an installed plugin,
+// without opening any. Go plugins are recorded under their file name, so the daiv- prefix
+// their files usually have is ignored.
+func namesInstalledPlugin(command string) bool {
+	manager, err := newPluginManager()
+	if err != nil {
+		return true
+	}
+
+	installed, err := manager.InstalledNames()
+	if err != nil {
+		// Let the loader report what is wrong
+		return true
+	}
+
+	for _, name := range installed {
+		if name == command || strings.TrimPrefix(name, "daiv-") == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findCommand returns the subcommand of the root command with the given name or alias.
+func findCommand(name string) *cobra.Command {
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Name() == name || slices.Contains(cmd.Aliases, name) {
+			return cmd
+		}
+	}
+	return nil
 }
 
 func loadConfigs() error {
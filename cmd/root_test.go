@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMountPluginCommandsLoadsPluginsOnlyForPluginCommands(t *testing.T) {
+	// The configuration isn't needed to decide, so it's never read from the user's home
+	configOnce.Do(func() {})
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pluginsDir := filepath.Join(home, ".daiv", "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Neither is started, only their names are looked at
+	if err := os.WriteFile(filepath.Join(pluginsDir, "daiv-jira"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginsDir, "daiv-slack.so"), []byte("plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := 0
+	original := loadPlugins
+	loadPlugins = func() { loaded++ }
+	t.Cleanup(func() { loadPlugins = original })
+
+	tests := []struct {
+		name string
+		args []string
+		load bool
+	}{
+		{"no arguments", nil, false},
+		{"help flag", []string{"--help"}, false},
+		{"short help flag", []string{"-h"}, false},
+		{"help flag with config", []string{"--config", "daiv.yaml", "--help"}, false},
+		{"help command", []string{"help"}, false},
+		{"help for a command", []string{"help", "standup"}, false},
+		{"built-in command", []string{"plugin", "list"}, false},
+		{"built-in command help", []string{"standup", "--help"}, false},
+		{"built-in command after a global flag", []string{"--github-repositories", "api,web", "standup"}, false},
+		{"completion", []string{"completion", "bash"}, false},
+		{"mistyped command", []string{"standpu"}, false},
+		{"plugin command", []string{"jira", "transition", "PROJ-1", "Done"}, true},
+		{"plugin command help", []string{"jira", "--help"}, true},
+		{"plugin command after a global flag", []string{"--config", "daiv.yaml", "jira"}, true},
+		{"plugin named by its file", []string{"daiv-jira", "transition"}, true},
+		{"Go plugin command", []string{"slack", "post"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			loaded = 0
+			mountPluginCommands(test.args)
+			if got := loaded > 0; got != test.load {
+				t.Errorf("mountPluginCommands(%q) loaded plugins = %v, want %v", test.args, got, test.load)
+			}
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"daiv/internal/activity"
 	"daiv/internal/llm"
 	"daiv/internal/plugin"
 
@@ -26,13 +29,17 @@ var standupCmd = &cobra.Command{
 		  - Checks for status updates on the tickets that happened yesterday
 		  - Gathers GitHub activity from watched repositories
 	`,
+	Annotations: map[string]string{usesPluginsAnnotation: ""},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := validateConfig(); err != nil {
 			slog.Error(err.Error())
 			os.Exit(1)
 		}
 
-		runStandup()
+		if err := runStandup(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
 	},
 }
 
@@ -63,18 +70,28 @@ func initFlags() {
 	standupCmd.Flags().String("to-time", time.Now().Truncate(24*time.Hour).Add(24*time.Hour - time.Nanosecond).Format(time.RFC3339), "End time for the report (RFC3339 format)")
 	standupCmd.Flags().Bool("no-progress", false, "Disable progress bar")
 	standupCmd.Flags().Bool("prompt", false, "Show the prompt instead of generating the report")
+	standupCmd.Flags().StringSlice("plugins", nil, "Only use these plugins for this report")
+	standupCmd.Flags().StringSlice("skip-plugins", nil, "Don't use these plugins for this report")
 
 	// Bind time flags to viper
 	viper.BindPFlag("fromTime", standupCmd.Flags().Lookup("from-time"))
 	viper.BindPFlag("toTime", standupCmd.Flags().Lookup("to-time"))
 	viper.BindPFlag("no-progress", standupCmd.Flags().Lookup("no-progress"))
 	viper.BindPFlag("prompt", standupCmd.Flags().Lookup("prompt"))
+
+	// Plugins are loaded before the command runs, so the selection goes through viper
+	viper.BindPFlag(plugin.OnlyPluginsKey, standupCmd.Flags().Lookup("plugins"))
+	viper.BindPFlag(plugin.SkipPluginsKey, standupCmd.Flags().Lookup("skip-plugins"))
 }
 
-func runStandup() error {
+func runStandup() (err error) {
 	registry := plugin.GetRegistry()
 
 	defer func() {
+		if err != nil {
+			registry.RunOnError("standup", err)
+		}
+
 		if err := registry.ShutdownAll(); err != nil {
 			slog.Error("Error shutting down plugins", "error", err)
 		}
@@ -85,14 +102,40 @@ func runStandup() error {
 		End:   viper.GetTime("toTime"),
 	}
 
-	standupContextPlugins := registry.GetStandupPlugins()
-	errChan := make(chan error, len(standupContextPlugins))
-	reportChan := make(chan string, len(standupContextPlugins)) // Make buffered channel
+	activityPlugins := registry.GetActivityPlugins()
+
+	// Plugins that report structured activity are not asked for their context as well
+	var standupContextPlugins []plug.StandupPlugin
+	for _, reporter := range registry.GetStandupPlugins() {
+		if !slices.ContainsFunc(activityPlugins, func(p plugin.ActivityPlugin) bool { return p.Name() == reporter.Name() }) {
+			standupContextPlugins = append(standupContextPlugins, reporter)
+		}
+	}
+
+	errChan := make(chan error, len(standupContextPlugins)+len(activityPlugins))
+
+	// Results are stored by index, so the prompt follows the registry's plugin order
+	reports := make([]string, len(standupContextPlugins))
+	reportedActivities := make([][]plugin.Activity, len(activityPlugins))
 
 	var wg sync.WaitGroup
-	for _, reporter := range standupContextPlugins {
+	for i, reporter := range activityPlugins {
 		wg.Add(1)
-		go func(r plug.StandupPlugin) {
+		go func(i int, r plugin.ActivityPlugin) {
+			defer wg.Done()
+			activities, err := r.GetActivity(timeRange)
+			if err != nil {
+				errChan <- fmt.Errorf("%s: %w", r.Name(), err)
+				return
+			}
+
+			reportedActivities[i] = activities
+		}(i, reporter)
+	}
+
+	for i, reporter := range standupContextPlugins {
+		wg.Add(1)
+		go func(i int, r plug.StandupPlugin) {
 			defer wg.Done()
 			standupContext, err := r.GetStandupContext(timeRange)
 			if err != nil {
@@ -100,28 +143,39 @@ func runStandup() error {
 				return
 			}
 
-			reportChan <- standupContext.String()
-		}(reporter)
+			reports[i] = standupContext.String()
+		}(i, reporter)
 	}
 
-	// Start a goroutine to close channels after all workers are done
-	go func() {
-		wg.Wait()
-		close(reportChan)
-		close(errChan)
-	}()
+	wg.Wait()
+	close(errChan)
 
 	var standupContexts []string
-	for report := range reportChan {
-		if report != "" {	
+	for _, report := range reports {
+		if report != "" {
 			standupContexts = append(standupContexts, report)
 		}
 	}
 
+	var activities []plugin.Activity
+	for _, reported := range reportedActivities {
+		activities = append(activities, reported...)
+	}
+
+	if len(activities) > 0 {
+		groups, unrelated := activity.GroupByTicket(activity.Dedupe(activity.LinkTickets(activities, viper.GetStringSlice("tickets.projects"))))
+		lookupTickets(registry, groups)
+
+		activityContext := plug.StandupContext{
+			PluginName: "activity",
+			Content:    activity.RenderByTicket(groups, unrelated),
+		}
+		standupContexts = append(standupContexts, activityContext.String())
+	}
+
 	for err := range errChan {
 		if err != nil {
-			slog.Error("Error getting standup context", "error", err)
-			return err
+			return fmt.Errorf("error getting standup context: %w", err)
 		}
 	}
 
@@ -129,6 +183,7 @@ func runStandup() error {
 Generate a standup report for the current day based on. 
 Just respond with the report and nothing else.
 Make sure to include the correct Jira ticket number if available. (e.g. [PBR-1234])
+Activity that is already grouped by ticket belongs to that ticket.
 It should follow the following format:
 ## Yesterday:
 - xxx
@@ -145,22 +200,45 @@ Here is the context for the report:
 
 	if viper.GetBool("prompt") {
 		fmt.Println(prompt)
-		os.Exit(0)
+		return nil
 	}
 
 	llmClient, err := llm.NewClient()
 	if err != nil {
-		fmt.Printf("Error creating LLM client: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("error creating LLM client: %w", err)
 	}
 
 	finalReport, err := llmClient.GenerateFromSinglePrompt(prompt)
 	if err != nil {
-		fmt.Printf("Error generating report: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("error generating report: %w", err)
 	}
 
 	fmt.Println(finalReport)
 
+	if err := registry.RunAfterReport("standup", finalReport); err != nil {
+		slog.Warn("Error running report hooks", "error", err)
+	}
+
 	return nil
 }
+
+// lookupTickets fills in the details of each ticket from the first ticket plugin that knows it.
+func lookupTickets(registry *plugin.Registry, groups []activity.TicketGroup) {
+	ticketPlugins := registry.GetTicketPlugins()
+
+	for i := range groups {
+		for _, ticketPlugin := range ticketPlugins {
+			ticket, err := ticketPlugin.LookupTicket(groups[i].Key)
+			if errors.Is(err, plugin.ErrTicketNotFound) {
+				continue
+			}
+			if err != nil {
+				slog.Warn("Error looking up ticket", "plugin", ticketPlugin.Name(), "ticket", groups[i].Key, "error", err)
+				continue
+			}
+
+			groups[i].Ticket = ticket
+			break
+		}
+	}
+}
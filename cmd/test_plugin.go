@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"daiv/internal/plugin"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	plug "github.com/iures/daivplug"
+	"github.com/spf13/cobra"
+)
+
+var testPluginCmd = &cobra.Command{
+	Use:   "test [plugin-name or path]",
+	Short: "Check a plugin against daiv's expectations",
+	Long: `Load a plugin through daiv's loader and check that it behaves the way daiv
+expects: its name is set and unique, its manifest declares valid config keys,
+Initialize accepts its settings, GetStandupContext works for several time ranges
+including ranges without activity, and Shutdown succeeds. Every call is timed.
+
+The plugin can be an installed plugin, a plugin file (.so or executable) or a
+plugin source directory, which is built like 'daiv plugin dev' does.
+
+Initialize is called with fixture values derived from the manifest, unless
+--use-config is set, in which case your configured settings are used.
+
+Example:
+  daiv plugin test my-plugin
+  daiv plugin test ./out/daiv-myplugin.so
+  daiv plugin test ./daiv-myplugin --use-config`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := args[0]
+		useConfig, _ := cmd.Flags().GetBool("use-config")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		p, installedFile, cleanup, err := openPluginForTest(target)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		// The other plugins are only looked up by name, none of them is opened
+		others, err := otherPluginNames(installedFile)
+		if err != nil {
+			return err
+		}
+
+		options := plugin.ConformanceOptions{
+			OtherPlugins: others,
+			Installed:    installedFile != "",
+			Timeout:      timeout,
+		}
+		if useConfig {
+			options.Settings = plugin.Settings(p)
+		}
+
+		fmt.Printf("Testing plugin %s\n\n", target)
+
+		var failed, warned int
+		for _, result := range plugin.RunConformance(p, options) {
+			fmt.Printf("%s  %-36s %8s", result.Status, result.Name, result.Duration.Round(10*time.Microsecond))
+			if result.Message != "" {
+				fmt.Printf("  %s", result.Message)
+			}
+			fmt.Println()
+
+			switch result.Status {
+			case plugin.CheckFail:
+				failed++
+			case plugin.CheckWarn:
+				warned++
+			}
+		}
+
+		fmt.Printf("\n%d failed, %d warnings\n", failed, warned)
+		if failed > 0 {
+			return fmt.Errorf("plugin %s failed %d check(s)", target, failed)
+		}
+
+		return nil
+	},
+}
+
+// openPluginForTest loads the plugin to test from a source directory, a plugin file or
+// the plugins directory, and returns its file name when it is an installed plugin
+func openPluginForTest(target string) (plug.Plugin, string, func(), error) {
+	noop := func() {}
+
+	info, err := os.Stat(target)
+	if err == nil && info.IsDir() {
+		binDir, err := os.MkdirTemp("", "daivplug-test-*")
+		if err != nil {
+			return nil, "", noop, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		cleanup := func() { os.RemoveAll(binDir) }
+
+		binary := filepath.Join(binDir, filepath.Base(filepath.Clean(target)))
+		if err := plugin.BuildDevPlugin(target, binary); err != nil {
+			cleanup()
+			return nil, "", noop, err
+		}
+
+		p, err := plugin.OpenProcessPlugin(binary)
+		if err != nil {
+			cleanup()
+			return nil, "", noop, err
+		}
+		return p, "", cleanup, nil
+	}
+
+	if err == nil {
+		p, err := plugin.OpenFile(target)
+		return p, "", noop, err
+	}
+
+	manager, err := newPluginManager()
+	if err != nil {
+		return nil, "", noop, err
+	}
+
+	path, err := manager.InstalledPath(target)
+	if err != nil {
+		return nil, "", noop, err
+	}
+
+	p, err := plugin.OpenFile(path)
+	return p, filepath.Base(path), noop, err
+}
+
+// otherPluginNames returns the names of the built-in and installed plugins, except the
+// installed file under test
+func otherPluginNames(exceptFile string) ([]string, error) {
+	// External plugins aren't loaded here, so the registry only holds the built-ins
+	names := plugin.GetRegistry().Names()
+
+	manager, err := newPluginManager()
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := manager.InstalledNames()
+	if err != nil {
+		return nil, err
+	}
+	for file, name := range installed {
+		if file != exceptFile {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func init() {
+	testPluginCmd.Flags().Bool("use-config", false, "Initialize the plugin with your configured settings instead of fixtures")
+	testPluginCmd.Flags().Duration("timeout", 30*time.Second, "Maximum duration of every call into the plugin")
+	pluginCmd.AddCommand(testPluginCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"daiv/internal/plugin"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var updateAllPlugins bool
+
+var updatePluginCmd = &cobra.Command{
+	Use:     "update [plugin-name]",
+	Aliases: []string{"upgrade"},
+	Short:   "Update installed plugins",
+	Long: `Update an installed plugin, or all of them, from the source recorded in plugins.lock.
+
+Plugins installed at a release tag move to the newest release tag, plugins
+installed from a branch or without a version follow that branch, and plugins
+pinned to a commit are left alone. The replaced version is kept so that it can
+be restored with 'daiv plugin rollback'.
+
+Example:
+  daiv plugin update my-plugin
+  daiv plugin update --all`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if updateAllPlugins {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manager, err := newPluginManager()
+		if err != nil {
+			return err
+		}
+
+		if !updateAllPlugins {
+			return updatePlugin(manager, args[0])
+		}
+
+		lock, err := manager.Lockfile()
+		if err != nil {
+			return err
+		}
+
+		if len(lock.Plugins) == 0 {
+			fmt.Println("No plugins recorded in " + plugin.LockfileName)
+			return nil
+		}
+
+		// Keep going when a plugin fails to update, the others may still succeed
+		var failed []string
+		for _, entry := range lock.Plugins {
+			if err := updatePlugin(manager, entry.Name); err != nil {
+				fmt.Printf("Failed to update %s: %v\n", entry.Name, err)
+				failed = append(failed, entry.Name)
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to update %d plugin(s): %v", len(failed), failed)
+		}
+
+		return nil
+	},
+}
+
+func updatePlugin(manager *plugin.PluginManager, name string) error {
+	err := manager.Update(name)
+	if errors.Is(err, plugin.ErrUpToDate) {
+		fmt.Printf("%s is up to date\n", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully updated plugin: %s\n", name)
+	return nil
+}
+
+func init() {
+	updatePluginCmd.Flags().BoolVar(&updateAllPlugins, "all", false, "Update all installed plugins")
+	pluginCmd.AddCommand(updatePluginCmd)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"daiv/internal/relevantprs"
+
+	"github.com/spf13/cobra"
+)
+
+var watchRelevantPrsCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch repositories and notify when new PRs match your keywords",
+	Long: `Polls the repositories configured under relevantPrs and emits a notification
+whenever an open pull request starts matching the configured keywords.
+
+Notifications are sent to the sinks configured under relevantPrs.watch.sinks
+(stdout, jsonl or webhook) and to any sink given with flags. When no sink is
+configured, notifications are logged to stdout.
+
+Pull requests that already matched when the watch started are only recorded,
+unless --notify-existing is given.
+
+Example:
+  daiv relevantPrs watch --interval 10m
+  daiv relevantPrs watch --jsonl ~/relevant-prs.jsonl
+  daiv relevantPrs watch --webhook https://hooks.slack.com/services/...`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := relevantprs.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w", err)
+		}
+
+		interval, _ := cmd.Flags().GetDuration("interval")
+		if interval <= 0 {
+			return fmt.Errorf("interval must be greater than zero")
+		}
+
+		sinkConfigs := cfg.Watch.Sinks
+		if path, _ := cmd.Flags().GetString("jsonl"); path != "" {
+			sinkConfigs = append(sinkConfigs, relevantprs.SinkConfig{Type: relevantprs.SinkTypeJSONL, Path: path})
+		}
+		if url, _ := cmd.Flags().GetString("webhook"); url != "" {
+			sinkConfigs = append(sinkConfigs, relevantprs.SinkConfig{Type: relevantprs.SinkTypeWebhook, URL: url})
+		}
+		if len(sinkConfigs) == 0 {
+			sinkConfigs = append(sinkConfigs, relevantprs.SinkConfig{Type: relevantprs.SinkTypeStdout})
+		}
+
+		var sinks []relevantprs.Sink
+		for _, sinkConfig := range sinkConfigs {
+			sink, err := relevantprs.NewSink(sinkConfig)
+			if err != nil {
+				return fmt.Errorf("invalid notification sink: %w", err)
+			}
+			sinks = append(sinks, sink)
+		}
+
+		statePath, err := relevantprs.DefaultStatePath()
+		if err != nil {
+			return fmt.Errorf("failed to get cache directory: %w", err)
+		}
+
+		watcher, err := relevantprs.NewWatcher(cfg.Repositories, sinks, statePath)
+		if err != nil {
+			return err
+		}
+		watcher.NotifyExisting, _ = cmd.Flags().GetBool("notify-existing")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("Watching %d repositories every %s\n", len(cfg.Repositories), interval)
+
+		return watcher.Run(ctx, interval)
+	},
+}
+
+func init() {
+	relevantPrsCmd.AddCommand(watchRelevantPrsCmd)
+
+	watchRelevantPrsCmd.Flags().Duration("interval", 10*time.Minute, "How often to poll the repositories")
+	watchRelevantPrsCmd.Flags().String("jsonl", "", "Append notifications to this JSONL file")
+	watchRelevantPrsCmd.Flags().String("webhook", "", "POST notifications as JSON to this URL")
+	watchRelevantPrsCmd.Flags().Bool("notify-existing", false, "Notify about PRs that already match when the watch starts")
+}
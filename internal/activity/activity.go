@@ -0,0 +1,141 @@
+// Package activity turns the structured activity reported by plugins into standup
+// prompt text.
+package activity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"daiv/internal/plugin"
+)
+
+// Dedupe merges activities that describe the same thing as reported by several plugins,
+// such as a ticket transition seen by both the Jira and the GitHub plugin, or the same
+// pull request reported twice. The result is in chronological order.
+func Dedupe(activities []plugin.Activity) []plugin.Activity {
+	var deduped []plugin.Activity
+	index := make(map[string]int)
+
+	for _, activity := range activities {
+		activity.TicketKeys = normalizeKeys(activity.TicketKeys)
+
+		key := identity(activity)
+		if i, ok := index[key]; ok {
+			deduped[i] = merge(deduped[i], activity)
+			continue
+		}
+
+		index[key] = len(deduped)
+		deduped = append(deduped, activity)
+	}
+
+	slices.SortStableFunc(deduped, func(a, b plugin.Activity) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return deduped
+}
+
+// identity returns what makes two activities the same. Ticket activities are identified by
+// their tickets and the state they moved to, since each tracker links tickets differently.
+func identity(activity plugin.Activity) string {
+	switch {
+	case activity.Kind == plugin.KindTicket && len(activity.TicketKeys) > 0:
+		key := "ticket:" + strings.Join(activity.TicketKeys, ",")
+		if activity.StateChange != nil {
+			key += ":" + strings.ToLower(activity.StateChange.To)
+		}
+		return key
+	case activity.URL != "":
+		return "url:" + strings.TrimSuffix(activity.URL, "/")
+	default:
+		return fmt.Sprintf("%s:%s:%s:%s", activity.Source, activity.Kind, activity.Title, activity.Time.Truncate(time.Minute))
+	}
+}
+
+// merge combines two reports of the same activity, keeping the earliest time and filling
+// in what only one of them knows.
+func merge(a, b plugin.Activity) plugin.Activity {
+	if b.Time.Before(a.Time) {
+		a.Time = b.Time
+	}
+
+	sources := strings.Split(a.Source, ", ")
+	if !slices.Contains(sources, b.Source) {
+		a.Source += ", " + b.Source
+	}
+
+	if a.Title == "" {
+		a.Title = b.Title
+	}
+	if a.URL == "" {
+		a.URL = b.URL
+	}
+	if a.Branch == "" {
+		a.Branch = b.Branch
+	}
+	if a.StateChange == nil {
+		a.StateChange = b.StateChange
+	}
+	a.TicketKeys = normalizeKeys(append(slices.Clone(a.TicketKeys), b.TicketKeys...))
+
+	return a
+}
+
+// normalizeKeys upper-cases ticket keys, sorts them and removes duplicates.
+func normalizeKeys(keys []string) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.ToUpper(strings.TrimSpace(key)); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
+
+// Render formats activities as a list with one line each, such as
+// "2025-02-18 10:12 pull_request [PROJ-123] Add retry (Open → Merged) https://... (github)".
+func Render(activities []plugin.Activity) string {
+	var lines []string
+	for _, activity := range activities {
+		lines = append(lines, renderLine(activity))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderLine(activity plugin.Activity) string {
+	var b strings.Builder
+
+	b.WriteString("- ")
+	b.WriteString(activity.Time.Local().Format("2006-01-02 15:04"))
+	if activity.Kind != "" {
+		b.WriteString(" " + activity.Kind)
+	}
+	if len(activity.TicketKeys) > 0 {
+		b.WriteString(" [" + strings.Join(activity.TicketKeys, ", ") + "]")
+	}
+	b.WriteString(" " + activity.Title)
+	if change := activity.StateChange; change != nil {
+		if change.From != "" {
+			fmt.Fprintf(&b, " (%s → %s)", change.From, change.To)
+		} else {
+			fmt.Fprintf(&b, " (→ %s)", change.To)
+		}
+	}
+	if activity.URL != "" {
+		b.WriteString(" " + activity.URL)
+	}
+	if activity.Source != "" {
+		b.WriteString(" (" + activity.Source + ")")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,215 @@
+package activity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"daiv/internal/plugin"
+)
+
+func TestDedupe(t *testing.T) {
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2025, 2, 18, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		activities []plugin.Activity
+		want       []plugin.Activity
+	}{
+		{
+			name: "ticket transition reported by two plugins",
+			activities: []plugin.Activity{
+				{Time: at(10, 5, 0), Source: "jira", Kind: plugin.KindTicket, Title: "Add retry", TicketKeys: []string{"PROJ-1"},
+					StateChange: &plugin.StateChange{From: "To Do", To: "In Progress"}, URL: "https://jira/PROJ-1"},
+				{Time: at(10, 2, 0), Source: "github", Kind: plugin.KindTicket, Title: "PROJ-1 moved", TicketKeys: []string{"proj-1 "},
+					StateChange: &plugin.StateChange{To: "in progress"}, Branch: "proj-1-retry"},
+			},
+			want: []plugin.Activity{
+				{Time: at(10, 2, 0), Source: "jira, github", Kind: plugin.KindTicket, Title: "Add retry", TicketKeys: []string{"PROJ-1"},
+					StateChange: &plugin.StateChange{From: "To Do", To: "In Progress"}, URL: "https://jira/PROJ-1", Branch: "proj-1-retry"},
+			},
+		},
+		{
+			name: "same ticket moved to different states",
+			activities: []plugin.Activity{
+				{Time: at(9, 0, 0), Source: "jira", Kind: plugin.KindTicket, TicketKeys: []string{"PROJ-1"}, StateChange: &plugin.StateChange{To: "In Progress"}},
+				{Time: at(15, 0, 0), Source: "jira", Kind: plugin.KindTicket, TicketKeys: []string{"PROJ-1"}, StateChange: &plugin.StateChange{To: "Done"}},
+			},
+			want: []plugin.Activity{
+				{Time: at(9, 0, 0), Source: "jira", Kind: plugin.KindTicket, TicketKeys: []string{"PROJ-1"}, StateChange: &plugin.StateChange{To: "In Progress"}},
+				{Time: at(15, 0, 0), Source: "jira", Kind: plugin.KindTicket, TicketKeys: []string{"PROJ-1"}, StateChange: &plugin.StateChange{To: "Done"}},
+			},
+		},
+		{
+			name: "pull request reported by URL with and without trailing slash",
+			activities: []plugin.Activity{
+				{Time: at(11, 0, 0), Source: "github", Kind: plugin.KindPullRequest, Title: "Add retry", URL: "https://github.com/acme/api/pull/7", TicketKeys: []string{"PROJ-2"}},
+				{Time: at(11, 30, 0), Source: "slack", Kind: plugin.KindPullRequest, Title: "Review please", URL: "https://github.com/acme/api/pull/7/", TicketKeys: []string{"PROJ-1", "proj-2"}},
+			},
+			want: []plugin.Activity{
+				{Time: at(11, 0, 0), Source: "github, slack", Kind: plugin.KindPullRequest, Title: "Add retry", URL: "https://github.com/acme/api/pull/7", TicketKeys: []string{"PROJ-1", "PROJ-2"}},
+			},
+		},
+		{
+			name: "a source is listed once",
+			activities: []plugin.Activity{
+				{Time: at(11, 0, 0), Source: "github", URL: "https://github.com/acme/api/pull/7"},
+				{Time: at(11, 0, 0), Source: "slack", URL: "https://github.com/acme/api/pull/7"},
+				{Time: at(11, 0, 0), Source: "github", URL: "https://github.com/acme/api/pull/7"},
+			},
+			want: []plugin.Activity{
+				{Time: at(11, 0, 0), Source: "github, slack", URL: "https://github.com/acme/api/pull/7"},
+			},
+		},
+		{
+			name: "activities without URL match within the same minute",
+			activities: []plugin.Activity{
+				{Time: at(12, 0, 10), Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo"},
+				{Time: at(12, 0, 50), Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo", Branch: "main"},
+				{Time: at(12, 1, 5), Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo"},
+				{Time: at(12, 0, 10), Source: "github", Kind: plugin.KindCommit, Title: "Fix typo"},
+			},
+			want: []plugin.Activity{
+				{Time: at(12, 0, 10), Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo", Branch: "main"},
+				{Time: at(12, 0, 10), Source: "github", Kind: plugin.KindCommit, Title: "Fix typo"},
+				{Time: at(12, 1, 5), Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo"},
+			},
+		},
+		{
+			name: "ticket activities without keys are identified by URL",
+			activities: []plugin.Activity{
+				{Time: at(8, 0, 0), Source: "jira", Kind: plugin.KindTicket, Title: "Triage", URL: "https://jira/PROJ-3"},
+				{Time: at(8, 5, 0), Source: "github", Kind: plugin.KindTicket, URL: "https://jira/PROJ-3/"},
+			},
+			want: []plugin.Activity{
+				{Time: at(8, 0, 0), Source: "jira, github", Kind: plugin.KindTicket, Title: "Triage", URL: "https://jira/PROJ-3"},
+			},
+		},
+		{
+			name: "chronological order",
+			activities: []plugin.Activity{
+				{Time: at(16, 0, 0), Source: "b", Title: "late"},
+				{Time: at(8, 0, 0), Source: "a", Title: "early"},
+				{Time: at(12, 0, 0), Source: "c", Title: "noon"},
+			},
+			want: []plugin.Activity{
+				{Time: at(8, 0, 0), Source: "a", Title: "early"},
+				{Time: at(12, 0, 0), Source: "c", Title: "noon"},
+				{Time: at(16, 0, 0), Source: "b", Title: "late"},
+			},
+		},
+		{
+			name: "empty ticket keys are dropped",
+			activities: []plugin.Activity{
+				{Time: at(8, 0, 0), Source: "a", Title: "x", TicketKeys: []string{" ", ""}},
+			},
+			want: []plugin.Activity{
+				{Time: at(8, 0, 0), Source: "a", Title: "x", TicketKeys: []string{}},
+			},
+		},
+		{
+			name: "nothing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Dedupe(test.activities)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Dedupe() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	first := plugin.Activity{
+		Time:        time.Date(2025, 2, 18, 10, 0, 0, 0, time.UTC),
+		Source:      "jira",
+		Title:       "First title",
+		TicketKeys:  []string{"PROJ-2"},
+		StateChange: &plugin.StateChange{From: "To Do", To: "Done"},
+	}
+	second := plugin.Activity{
+		Time:        time.Date(2025, 2, 18, 9, 0, 0, 0, time.UTC),
+		Source:      "github",
+		Title:       "Second title",
+		URL:         "https://github.com/acme/api/pull/7",
+		Branch:      "proj-2-fix",
+		TicketKeys:  []string{"PROJ-1", "PROJ-2"},
+		StateChange: &plugin.StateChange{To: "Merged"},
+	}
+
+	got := merge(first, second)
+	want := plugin.Activity{
+		Time:        second.Time,
+		Source:      "jira, github",
+		Title:       "First title",
+		URL:         "https://github.com/acme/api/pull/7",
+		Branch:      "proj-2-fix",
+		TicketKeys:  []string{"PROJ-1", "PROJ-2"},
+		StateChange: &plugin.StateChange{From: "To Do", To: "Done"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merge() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// The keys of the first report aren't modified in place
+	if !reflect.DeepEqual(first.TicketKeys, []string{"PROJ-2"}) {
+		t.Errorf("merge() modified the ticket keys of its argument: %v", first.TicketKeys)
+	}
+}
+
+func TestRender(t *testing.T) {
+	at := time.Date(2025, 2, 18, 10, 12, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		activities []plugin.Activity
+		want       string
+	}{
+		{
+			name: "every field",
+			activities: []plugin.Activity{{
+				Time: at, Source: "github", Kind: plugin.KindPullRequest, Title: "Add retry",
+				URL: "https://github.com/acme/api/pull/7", TicketKeys: []string{"PROJ-1", "PROJ-2"},
+				StateChange: &plugin.StateChange{From: "Open", To: "Merged"},
+			}},
+			want: "- 2025-02-18 10:12 pull_request [PROJ-1, PROJ-2] Add retry (Open → Merged) https://github.com/acme/api/pull/7 (github)",
+		},
+		{
+			name: "state change without previous state",
+			activities: []plugin.Activity{{
+				Time: at, Source: "jira", Kind: plugin.KindTicket, Title: "Triage", StateChange: &plugin.StateChange{To: "In Progress"},
+			}},
+			want: "- 2025-02-18 10:12 ticket Triage (→ In Progress) (jira)",
+		},
+		{
+			name:       "only a title",
+			activities: []plugin.Activity{{Time: at, Title: "Something happened"}},
+			want:       "- 2025-02-18 10:12 Something happened",
+		},
+		{
+			name: "one line each",
+			activities: []plugin.Activity{
+				{Time: at, Source: "localgit", Kind: plugin.KindCommit, Title: "Fix typo"},
+				{Time: at.Add(time.Hour), Source: "jira, github", Kind: plugin.KindComment, Title: "Replied"},
+			},
+			want: "- 2025-02-18 10:12 commit Fix typo (localgit)\n- 2025-02-18 11:12 comment Replied (jira, github)",
+		},
+		{
+			name: "nothing",
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Render(test.activities); got != test.want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
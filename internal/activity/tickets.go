@@ -0,0 +1,152 @@
+package activity
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"daiv/internal/plugin"
+)
+
+// ticketKeyPattern matches Jira style ticket keys such as PROJ-123. Project keys are
+// upper case and at least two characters long, so that "v-2" or "py-3" aren't keys.
+var ticketKeyPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]+)-([1-9][0-9]*)\b`)
+
+// notProjects are the prefixes of well-known identifiers that look like ticket keys, such
+// as UTF-8, ISO-8601 or SHA-256. They only count as projects when configured.
+var notProjects = map[string]bool{
+	"AES": true, "CVE": true, "CWE": true, "ECMA": true, "ISO": true,
+	"RFC": true, "RSA": true, "SHA": true, "UTF": true,
+}
+
+// LinkTickets adds the ticket keys mentioned in the titles and branches of activities to
+// their TicketKeys. Titles, such as PR titles and commit messages, must spell keys in
+// upper case, as in "PROJ-123: Fix sync". Branch names are often lower case, so
+// "proj-123-fix-sync" counts too, but only for projects whose keys appear elsewhere.
+// When projects are configured, only their keys are found, in titles and branches alike.
+func LinkTickets(activities []plugin.Activity, projects []string) []plugin.Activity {
+	configured := make(map[string]bool)
+	for _, project := range projects {
+		configured[strings.ToUpper(strings.TrimSpace(project))] = true
+	}
+
+	inTitle := func(project string) bool { return !notProjects[project] }
+	if len(configured) > 0 {
+		inTitle = func(project string) bool { return configured[project] }
+	}
+
+	seen := make(map[string]bool)
+	for _, activity := range activities {
+		for _, key := range activity.TicketKeys {
+			seen[project(key)] = true
+		}
+		for _, key := range findKeys(activity.Title, inTitle) {
+			seen[project(key)] = true
+		}
+	}
+
+	inBranch := func(project string) bool { return seen[project] }
+	if len(configured) > 0 {
+		inBranch = inTitle
+	}
+
+	linked := make([]plugin.Activity, 0, len(activities))
+	for _, activity := range activities {
+		keys := slices.Clone(activity.TicketKeys)
+		keys = append(keys, findKeys(activity.Title, inTitle)...)
+		keys = append(keys, findKeys(strings.ToUpper(activity.Branch), inBranch)...)
+
+		activity.TicketKeys = normalizeKeys(keys)
+		linked = append(linked, activity)
+	}
+
+	return linked
+}
+
+// findKeys returns the ticket keys in text whose project is accepted.
+func findKeys(text string, accept func(project string) bool) []string {
+	var keys []string
+	for _, match := range ticketKeyPattern.FindAllStringSubmatch(text, -1) {
+		if accept(match[1]) {
+			keys = append(keys, match[0])
+		}
+	}
+	return keys
+}
+
+func project(key string) string {
+	prefix, _, _ := strings.Cut(strings.ToUpper(key), "-")
+	return prefix
+}
+
+// TicketGroup is the activity related to a ticket.
+type TicketGroup struct {
+	Key string
+	// Ticket holds the details of the ticket, when a ticket plugin knows it.
+	Ticket     *plugin.Ticket
+	Activities []plugin.Activity
+}
+
+// GroupByTicket groups activities by the tickets they relate to, in the order each ticket
+// was first worked on. Activities relating to several tickets are part of each group;
+// the ones without a ticket are returned separately.
+func GroupByTicket(activities []plugin.Activity) ([]TicketGroup, []plugin.Activity) {
+	var groups []TicketGroup
+	var unrelated []plugin.Activity
+	index := make(map[string]int)
+
+	for _, activity := range activities {
+		if len(activity.TicketKeys) == 0 {
+			unrelated = append(unrelated, activity)
+			continue
+		}
+
+		for _, key := range activity.TicketKeys {
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, TicketGroup{Key: key})
+			}
+			groups[i].Activities = append(groups[i].Activities, activity)
+		}
+	}
+
+	return groups, unrelated
+}
+
+// RenderByTicket formats activity grouped by ticket, followed by the unrelated activity.
+// Within a group, the ticket key isn't repeated on every line.
+func RenderByTicket(groups []TicketGroup, unrelated []plugin.Activity) string {
+	var sections []string
+
+	for _, group := range groups {
+		var b strings.Builder
+
+		b.WriteString("### " + group.Key)
+		if ticket := group.Ticket; ticket != nil {
+			if ticket.Title != "" {
+				b.WriteString(": " + ticket.Title)
+			}
+			if ticket.Status != "" {
+				fmt.Fprintf(&b, " (%s)", ticket.Status)
+			}
+		}
+
+		for _, activity := range group.Activities {
+			activity.TicketKeys = slices.DeleteFunc(slices.Clone(activity.TicketKeys), func(key string) bool {
+				return key == group.Key
+			})
+			b.WriteString("\n" + renderLine(activity))
+		}
+
+		sections = append(sections, b.String())
+	}
+
+	if len(unrelated) > 0 {
+		sections = append(sections, "### No ticket\n"+Render(unrelated))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,141 @@
+package activity
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"daiv/internal/plugin"
+)
+
+func TestLinkTickets(t *testing.T) {
+	tests := []struct {
+		name       string
+		activities []plugin.Activity
+		projects   []string
+		want       [][]string
+	}{
+		{
+			name: "keys in titles",
+			activities: []plugin.Activity{
+				{Title: "PROJ-123: Fix sync"},
+				{Title: "Fix sync (OPS2-7, PROJ-123)"},
+				{Title: "No ticket here"},
+			},
+			want: [][]string{{"PROJ-123"}, {"OPS2-7", "PROJ-123"}, nil},
+		},
+		{
+			name: "identifiers that look like keys",
+			activities: []plugin.Activity{
+				{Title: "Bump to UTF-8"},
+				{Title: "Parse ISO-8601 dates"},
+				{Title: "Verify SHA-256 checksums"},
+				{Title: "Release v-2"},
+				{Title: "Support py-3"},
+				{Title: "Fix X-1 and proj-123"},
+				{Title: "PROJ-0123 and PROJ-0"},
+			},
+			want: [][]string{nil, nil, nil, nil, nil, nil, nil},
+		},
+		{
+			name: "keys reported by plugins are kept",
+			activities: []plugin.Activity{
+				{Title: "Sync job", TicketKeys: []string{"proj-9"}},
+				{Title: "Move to UTF-8", TicketKeys: []string{"PROJ-9"}},
+			},
+			want: [][]string{{"PROJ-9"}, {"PROJ-9"}},
+		},
+		{
+			name: "branches of projects seen elsewhere",
+			activities: []plugin.Activity{
+				{Title: "PROJ-1: Add retry"},
+				{Title: "Fix sync", Branch: "proj-123-fix-sync"},
+				{Title: "Fix sync", Branch: "feature/PROJ-124"},
+				{Title: "Unicode", Branch: "utf-8-support"},
+				{Title: "Python", Branch: "py-3"},
+				{Title: "Other", Branch: "other-5-fix"},
+			},
+			want: [][]string{{"PROJ-1"}, {"PROJ-123"}, {"PROJ-124"}, nil, nil, nil},
+		},
+		{
+			name: "projects reported by plugins count for branches",
+			activities: []plugin.Activity{
+				{Title: "Ticket moved", TicketKeys: []string{"OPS-1"}},
+				{Title: "Fix deploy", Branch: "ops-42-deploy"},
+			},
+			want: [][]string{{"OPS-1"}, {"OPS-42"}},
+		},
+		{
+			name:     "configured projects",
+			projects: []string{"proj", " ISO "},
+			activities: []plugin.Activity{
+				{Title: "PROJ-1: Add retry"},
+				{Title: "OPS-2: Fix deploy"},
+				{Title: "Bump to UTF-8"},
+				{Title: "ISO-8601 is the ticket here"},
+				{Title: "Fix sync", Branch: "proj-123-fix-sync"},
+				{Title: "Fix deploy", Branch: "ops-2-deploy"},
+			},
+			want: [][]string{{"PROJ-1"}, nil, nil, {"ISO-8601"}, {"PROJ-123"}, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			linked := LinkTickets(test.activities, test.projects)
+			if len(linked) != len(test.want) {
+				t.Fatalf("LinkTickets() returned %d activities, want %d", len(linked), len(test.want))
+			}
+			for i, activity := range linked {
+				if !reflect.DeepEqual(activity.TicketKeys, test.want[i]) {
+					t.Errorf("activity %d %q: TicketKeys = %q, want %q", i, activity.Title, activity.TicketKeys, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGroupByTicket(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2025, 2, 18, hour, 0, 0, 0, time.UTC)
+	}
+
+	activities := LinkTickets([]plugin.Activity{
+		{Time: at(9), Title: "PROJ-2: Add retry"},
+		{Time: at(10), Title: "Bump to UTF-8"},
+		{Time: at(11), Title: "Parse ISO-8601 dates for PROJ-1"},
+		{Time: at(12), Title: "Verify SHA-256 checksums", Branch: "proj-2-checksums"},
+		{Time: at(13), Title: "Release v-2"},
+		{Time: at(14), Title: "PROJ-1 and PROJ-2"},
+	}, nil)
+
+	groups, unrelated := GroupByTicket(activities)
+
+	var keys []string
+	titles := make(map[string][]string)
+	for _, group := range groups {
+		keys = append(keys, group.Key)
+		for _, activity := range group.Activities {
+			titles[group.Key] = append(titles[group.Key], activity.Title)
+		}
+	}
+
+	if want := []string{"PROJ-2", "PROJ-1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("groups = %q, want %q", keys, want)
+	}
+	wantTitles := map[string][]string{
+		"PROJ-2": {"PROJ-2: Add retry", "Verify SHA-256 checksums", "PROJ-1 and PROJ-2"},
+		"PROJ-1": {"Parse ISO-8601 dates for PROJ-1", "PROJ-1 and PROJ-2"},
+	}
+	if !reflect.DeepEqual(titles, wantTitles) {
+		t.Errorf("grouped titles = %q, want %q", titles, wantTitles)
+	}
+
+	var unrelatedTitles []string
+	for _, activity := range unrelated {
+		unrelatedTitles = append(unrelatedTitles, activity.Title)
+	}
+	if want := []string{"Bump to UTF-8", "Release v-2"}; !reflect.DeepEqual(unrelatedTitles, want) {
+		t.Errorf("unrelated = %q, want %q", unrelatedTitles, want)
+	}
+}
@@ -0,0 +1,19 @@
+package github
+
+import (
+	"github.com/google/go-github/v68/github"
+	"github.com/spf13/viper"
+)
+
+// NewGithubClient creates a GitHub client authenticated with the configured token.
+// When no token is configured an unauthenticated client is returned, which is
+// enough for public repositories but subject to much lower rate limits.
+func NewGithubClient() (*github.Client, error) {
+	client := github.NewClient(nil)
+
+	if token := viper.GetString("github.token"); token != "" {
+		client = client.WithAuthToken(token)
+	}
+
+	return client, nil
+}
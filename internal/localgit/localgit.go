@@ -0,0 +1,474 @@
+// Package localgit is a built-in plugin that reports the user's work from local git
+// repositories: their commits, branch switches and stashes. It only runs git on disk,
+// so it works offline and without any API token.
+package localgit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"daiv/internal/activity"
+	"daiv/internal/plugin"
+
+	plug "github.com/iures/daivplug"
+)
+
+const (
+	RepositoriesKey = "localgit.repositories"
+	DirectoryKey    = "localgit.directory"
+	AuthorsKey      = "localgit.authors"
+)
+
+// Activity kinds reported besides commits.
+const (
+	KindCheckout = "checkout"
+	KindStash    = "stash"
+)
+
+// gitTimeout limits each git command, so a broken repository doesn't hold up the standup.
+const gitTimeout = 30 * time.Second
+
+// skippedDirs are not searched for repositories when scanning a directory.
+var skippedDirs = []string{"node_modules", "vendor"}
+
+// Plugin reports activity from the configured repositories and the repositories found
+// under the configured directory.
+type Plugin struct {
+	repositories []string
+	directory    string
+	authors      []string
+}
+
+// New creates the local git plugin.
+func New() *Plugin {
+	return &Plugin{}
+}
+
+func (p *Plugin) Name() string {
+	return "localgit"
+}
+
+func (p *Plugin) Manifest() *plug.PluginManifest {
+	return &plug.PluginManifest{
+		ConfigKeys: []plug.ConfigKey{
+			{
+				Type:        plug.ConfigTypeMultiline,
+				Key:         RepositoriesKey,
+				Name:        "Repositories",
+				Description: "Paths of the local repositories to report on, one per line",
+			},
+			{
+				Type:        plug.ConfigTypeString,
+				Key:         DirectoryKey,
+				Name:        "Repositories directory",
+				Description: "Directory whose repositories are all reported on, e.g. ~/code",
+			},
+			{
+				Type:        plug.ConfigTypeMultiline,
+				Key:         AuthorsKey,
+				Name:        "Author emails",
+				Description: "Emails your commits are authored with, one per line (default: git config user.email)",
+			},
+		},
+	}
+}
+
+func (p *Plugin) Initialize(settings map[string]any) error {
+	p.repositories = stringList(settings[RepositoriesKey])
+	p.directory, _ = settings[DirectoryKey].(string)
+	p.authors = stringList(settings[AuthorsKey])
+
+	for i, repository := range p.repositories {
+		path, err := expandHome(repository)
+		if err != nil {
+			return err
+		}
+		p.repositories[i] = path
+	}
+
+	directory, err := expandHome(p.directory)
+	if err != nil {
+		return err
+	}
+	p.directory = directory
+
+	return nil
+}
+
+func (p *Plugin) Shutdown() error {
+	return nil
+}
+
+func (p *Plugin) GetStandupContext(timeRange plug.TimeRange) (plug.StandupContext, error) {
+	activities, err := p.GetActivity(timeRange)
+	if err != nil {
+		return plug.StandupContext{}, err
+	}
+
+	return plug.StandupContext{
+		PluginName: p.Name(),
+		Content:    activity.Render(activity.Dedupe(activities)),
+	}, nil
+}
+
+// GetActivity returns the user's commits, branch switches and stashes in the time range.
+// Repositories that can't be read are skipped with a warning.
+func (p *Plugin) GetActivity(timeRange plug.TimeRange) ([]plugin.Activity, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("failed to find git: %w", err)
+	}
+
+	repositories, err := p.findRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	var activities []plugin.Activity
+	for _, repository := range repositories {
+		found, err := p.repositoryActivity(repository, timeRange)
+		if err != nil {
+			slog.Warn("Skipping repository", "plugin", p.Name(), "repository", repository, "error", err)
+			continue
+		}
+		activities = append(activities, found...)
+	}
+
+	return activities, nil
+}
+
+// findRepositories returns the configured repositories and those under the configured
+// directory, without duplicates.
+func (p *Plugin) findRepositories() ([]string, error) {
+	var repositories []string
+	add := func(path string) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if !slices.Contains(repositories, path) {
+			repositories = append(repositories, path)
+		}
+	}
+
+	for _, repository := range p.repositories {
+		add(repository)
+	}
+
+	if p.directory == "" {
+		return repositories, nil
+	}
+
+	err := filepath.WalkDir(p.directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, an unreadable root is reported
+			if path == p.directory {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		name := entry.Name()
+		if path != p.directory && (strings.HasPrefix(name, ".") || slices.Contains(skippedDirs, name)) {
+			return fs.SkipDir
+		}
+
+		// .git is a directory in clones and a file in worktrees and submodules
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			add(path)
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s for repositories: %w", p.directory, err)
+	}
+
+	return repositories, nil
+}
+
+func (p *Plugin) repositoryActivity(repository string, timeRange plug.TimeRange) ([]plugin.Activity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	name := filepath.Base(repository)
+
+	authors := p.authors
+	if len(authors) == 0 {
+		email, err := runGit(ctx, repository, "config", "user.email")
+		if err != nil || strings.TrimSpace(email) == "" {
+			return nil, fmt.Errorf("no author configured, set %s or git's user.email", AuthorsKey)
+		}
+		authors = []string{strings.TrimSpace(email)}
+	}
+
+	commits, err := commits(ctx, repository, name, authors, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	checkouts, err := checkouts(ctx, repository, name, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	stashes, err := stashes(ctx, repository, name, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := append(commits, checkouts...)
+	activities = append(activities, stashes...)
+	for i := range activities {
+		activities[i].Source = p.Name()
+	}
+
+	return activities, nil
+}
+
+// commits returns the commits by the authors on any local branch, by author date.
+func commits(ctx context.Context, repository, name string, authors []string, timeRange plug.TimeRange) ([]plugin.Activity, error) {
+	// The commit date is never before the author date, so --since only skips older commits
+	// Emails are matched as fixed strings, git would take "alice+work@" as a pattern
+	args := []string{"log", "--branches", "--source", "--fixed-strings", "--format=%aI%x1f%S%x1f%s", "--since=" + timeRange.Start.Format(time.RFC3339)}
+	for _, author := range authors {
+		args = append(args, "--author="+author)
+	}
+
+	output, err := runGit(ctx, repository, args...)
+	if err != nil {
+		// A repository without commits has no branches to log
+		if isEmptyRepository(ctx, repository) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseCommits(output, name, timeRange), nil
+}
+
+// parseCommits parses the output of git log for commits.
+func parseCommits(output, name string, timeRange plug.TimeRange) []plugin.Activity {
+	var activities []plugin.Activity
+	for _, line := range lines(output) {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+
+		authored, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil || !inRange(authored, timeRange) {
+			continue
+		}
+
+		activities = append(activities, plugin.Activity{
+			Time:   authored,
+			Kind:   plugin.KindCommit,
+			Title:  fmt.Sprintf("%s: %s", name, fields[2]),
+			Branch: fields[1],
+		})
+	}
+
+	return activities
+}
+
+// checkoutPattern matches the reflog message git writes when switching branches.
+var checkoutPattern = regexp.MustCompile(`^checkout: moving from (\S+) to (\S+)$`)
+
+// checkouts returns the branch switches recorded in the HEAD reflog. The reflog is local
+// to the clone, so everything in it was done by the user.
+func checkouts(ctx context.Context, repository, name string, timeRange plug.TimeRange) ([]plugin.Activity, error) {
+	output, err := runGit(ctx, repository, "log", "--walk-reflogs", "--date=unix", "--format=%gd%x1f%gs", "HEAD")
+	if err != nil {
+		if isEmptyRepository(ctx, repository) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseCheckouts(output, name, timeRange), nil
+}
+
+// parseCheckouts parses the output of git log --walk-reflogs for branch switches.
+func parseCheckouts(output, name string, timeRange plug.TimeRange) []plugin.Activity {
+	var activities []plugin.Activity
+	for _, line := range lines(output) {
+		selector, subject, ok := strings.Cut(line, "\x1f")
+		if !ok {
+			continue
+		}
+
+		match := checkoutPattern.FindStringSubmatch(subject)
+		if match == nil || match[1] == match[2] {
+			continue
+		}
+
+		switched, ok := reflogTime(selector)
+		if !ok || !inRange(switched, timeRange) {
+			continue
+		}
+
+		activities = append(activities, plugin.Activity{
+			Time:        switched,
+			Kind:        KindCheckout,
+			Title:       fmt.Sprintf("%s: switched branch", name),
+			Branch:      match[2],
+			StateChange: &plugin.StateChange{From: match[1], To: match[2]},
+		})
+	}
+
+	return activities
+}
+
+// stashPattern matches the messages of stashes, "WIP on branch: ..." or "On branch: ...".
+var stashPattern = regexp.MustCompile(`^(WIP on|On) ([^:]+): (.*)$`)
+
+// stashes returns the stashes created in the time range that are still in the stash list.
+func stashes(ctx context.Context, repository, name string, timeRange plug.TimeRange) ([]plugin.Activity, error) {
+	output, err := runGit(ctx, repository, "stash", "list", "--format=%ct%x1f%gs")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStashes(output, name, timeRange), nil
+}
+
+// parseStashes parses the output of git stash list.
+func parseStashes(output, name string, timeRange plug.TimeRange) []plugin.Activity {
+	var activities []plugin.Activity
+	for _, line := range lines(output) {
+		timestamp, subject, ok := strings.Cut(line, "\x1f")
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+		stashed := time.Unix(seconds, 0)
+		if !inRange(stashed, timeRange) {
+			continue
+		}
+
+		stash := plugin.Activity{
+			Time:  stashed,
+			Kind:  KindStash,
+			Title: fmt.Sprintf("%s: stashed %s", name, subject),
+		}
+		// A "WIP on" message describes the commit the stash was made on, not the stash
+		if match := stashPattern.FindStringSubmatch(subject); match != nil {
+			stash.Branch = match[2]
+			stash.Title = fmt.Sprintf("%s: stashed %s", name, match[3])
+			if match[1] == "WIP on" {
+				stash.Title = fmt.Sprintf("%s: stashed work in progress", name)
+			}
+		}
+
+		activities = append(activities, stash)
+	}
+
+	return activities
+}
+
+// reflogTime parses the time of a reflog selector printed with --date=unix, e.g. HEAD@{1700000000}.
+func reflogTime(selector string) (time.Time, bool) {
+	start := strings.LastIndex(selector, "@{")
+	if start < 0 || !strings.HasSuffix(selector, "}") {
+		return time.Time{}, false
+	}
+
+	seconds, err := strconv.ParseInt(selector[start+2:len(selector)-1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
+
+// inRange reports whether t is within the time range, including its bounds.
+func inRange(t time.Time, timeRange plug.TimeRange) bool {
+	return !t.Before(timeRange.Start) && !t.After(timeRange.End)
+}
+
+// isEmptyRepository reports whether a repository has no commits yet.
+func isEmptyRepository(ctx context.Context, repository string) bool {
+	_, err := runGit(ctx, repository, "rev-parse", "--verify", "--quiet", "HEAD")
+	return err != nil
+}
+
+// runGit runs a git command in the given repository and returns its output.
+func runGit(ctx context.Context, path string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", path}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// lines returns the non-empty lines of a command's output.
+func lines(output string) []string {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// stringList converts a list setting, read from the config file or entered one per line, to strings.
+func stringList(value any) []string {
+	var values []string
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+	case string:
+		values = strings.Split(v, "\n")
+	}
+
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
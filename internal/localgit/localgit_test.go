@@ -0,0 +1,258 @@
+package localgit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"daiv/internal/plugin"
+
+	plug "github.com/iures/daivplug"
+)
+
+var testRange = plug.TimeRange{
+	Start: time.Date(2025, 2, 18, 0, 0, 0, 0, time.UTC),
+	End:   time.Date(2025, 2, 18, 23, 59, 59, 0, time.UTC),
+}
+
+func TestParseCommits(t *testing.T) {
+	output := strings.Join([]string{
+		"2025-02-18T10:12:00+01:00\x1fmain\x1fAdd retry to the sync job",
+		"2025-02-18T15:00:00Z\x1ffeature/proj-1\x1fPROJ-1: Fix: subjects with colons",
+		"2025-02-17T23:59:59Z\x1frefs/heads/main\x1fBefore the range",
+		"2025-02-19T00:00:00Z\x1frefs/heads/main\x1fAfter the range",
+		"not a date\x1frefs/heads/main\x1fBroken date",
+		"2025-02-18T12:00:00Z\x1fmissing fields",
+		"",
+	}, "\n")
+
+	want := []plugin.Activity{
+		{Time: time.Date(2025, 2, 18, 9, 12, 0, 0, time.UTC), Kind: plugin.KindCommit, Title: "api: Add retry to the sync job", Branch: "main"},
+		{Time: time.Date(2025, 2, 18, 15, 0, 0, 0, time.UTC), Kind: plugin.KindCommit, Title: "api: PROJ-1: Fix: subjects with colons", Branch: "feature/proj-1"},
+	}
+
+	got := parseCommits(output, "api", testRange)
+	if len(got) != len(want) {
+		t.Fatalf("parseCommits() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Time.Equal(want[i].Time) || got[i].Kind != want[i].Kind || got[i].Title != want[i].Title || got[i].Branch != want[i].Branch {
+			t.Errorf("parseCommits()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCheckouts(t *testing.T) {
+	inRange := time.Date(2025, 2, 18, 9, 0, 0, 0, time.UTC).Unix()
+	before := testRange.Start.Add(-time.Second).Unix()
+
+	tests := []struct {
+		name string
+		line string
+		want *plugin.Activity
+	}{
+		{
+			name: "branch switch",
+			line: "HEAD@{" + itoa(inRange) + "}\x1fcheckout: moving from main to feature/proj-1",
+			want: &plugin.Activity{
+				Time: time.Unix(inRange, 0), Kind: KindCheckout, Title: "api: switched branch", Branch: "feature/proj-1",
+				StateChange: &plugin.StateChange{From: "main", To: "feature/proj-1"},
+			},
+		},
+		{
+			name: "checkout of the current branch",
+			line: "HEAD@{" + itoa(inRange) + "}\x1fcheckout: moving from main to main",
+		},
+		{
+			name: "not a checkout",
+			line: "HEAD@{" + itoa(inRange) + "}\x1fcommit: Add retry",
+		},
+		{
+			name: "before the range",
+			line: "HEAD@{" + itoa(before) + "}\x1fcheckout: moving from main to develop",
+		},
+		{
+			name: "selector without a time",
+			line: "HEAD@{0}x\x1fcheckout: moving from main to develop",
+		},
+		{
+			name: "missing subject",
+			line: "HEAD@{" + itoa(inRange) + "}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseCheckouts(test.line+"\n", "api", testRange)
+			if test.want == nil {
+				if len(got) != 0 {
+					t.Errorf("parseCheckouts() = %+v, want nothing", got)
+				}
+				return
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], *test.want) {
+				t.Errorf("parseCheckouts() = %+v, want %+v", got, *test.want)
+			}
+		})
+	}
+}
+
+func TestParseStashes(t *testing.T) {
+	stashed := time.Date(2025, 2, 18, 11, 0, 0, 0, time.UTC).Unix()
+
+	tests := []struct {
+		name    string
+		subject string
+		time    int64
+		want    *plugin.Activity
+	}{
+		{
+			name:    "stash with a message",
+			subject: "On feature/proj-1: half done retry",
+			time:    stashed,
+			want:    &plugin.Activity{Time: time.Unix(stashed, 0), Kind: KindStash, Title: "api: stashed half done retry", Branch: "feature/proj-1"},
+		},
+		{
+			name:    "stash without a message",
+			subject: "WIP on main: 1a2b3c4 Add retry",
+			time:    stashed,
+			want:    &plugin.Activity{Time: time.Unix(stashed, 0), Kind: KindStash, Title: "api: stashed work in progress", Branch: "main"},
+		},
+		{
+			name:    "message with a colon",
+			subject: "On main: todo: finish",
+			time:    stashed,
+			want:    &plugin.Activity{Time: time.Unix(stashed, 0), Kind: KindStash, Title: "api: stashed todo: finish", Branch: "main"},
+		},
+		{
+			name:    "unknown message format",
+			subject: "autostash",
+			time:    stashed,
+			want:    &plugin.Activity{Time: time.Unix(stashed, 0), Kind: KindStash, Title: "api: stashed autostash"},
+		},
+		{
+			name:    "after the range",
+			subject: "On main: later",
+			time:    testRange.End.Add(time.Second).Unix(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseStashes(itoa(test.time)+"\x1f"+test.subject+"\n", "api", testRange)
+			if test.want == nil {
+				if len(got) != 0 {
+					t.Errorf("parseStashes() = %+v, want nothing", got)
+				}
+				return
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], *test.want) {
+				t.Errorf("parseStashes() = %+v, want %+v", got, *test.want)
+			}
+		})
+	}
+
+	if got := parseStashes("not a time\x1fOn main: x\n", "api", testRange); len(got) != 0 {
+		t.Errorf("parseStashes() with a broken time = %+v, want nothing", got)
+	}
+}
+
+func TestReflogTime(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     time.Time
+		ok       bool
+	}{
+		{"HEAD@{1700000000}", time.Unix(1700000000, 0), true},
+		{"refs/heads/feature@{x}@{1700000001}", time.Unix(1700000001, 0), true},
+		{"HEAD@{0}", time.Unix(0, 0), true},
+		{"HEAD@{2 hours ago}", time.Time{}, false},
+		{"HEAD@{1700000000", time.Time{}, false},
+		{"HEAD", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := reflogTime(test.selector)
+		if ok != test.ok || !got.Equal(test.want) {
+			t.Errorf("reflogTime(%q) = %v, %v, want %v, %v", test.selector, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repository := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(repository, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	git := func(author string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repository}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL="+author,
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL="+author,
+			"GIT_AUTHOR_DATE="+now.Format(time.RFC3339), "GIT_COMMITTER_DATE="+now.Format(time.RFC3339),
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+	}
+
+	const me = "alice+work@example.com"
+	git(me, "init", "-q", "-b", "main")
+	// Escaped for git's basic regular expressions, "alice\+work" matched this author
+	// instead of the email itself
+	git("alicework@example.com", "commit", "-q", "--allow-empty", "-m", "Someone else's")
+	git("bob@example.com", "commit", "-q", "--allow-empty", "-m", "Bob's")
+	git(me, "checkout", "-q", "-b", "feature")
+	git(me, "commit", "-q", "--allow-empty", "-m", "Mine")
+	if err := os.WriteFile(filepath.Join(repository, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(me, "add", "notes.txt")
+	git(me, "stash", "push", "-q", "-m", "half done")
+
+	p := New()
+	if err := p.Initialize(map[string]any{RepositoriesKey: repository, AuthorsKey: me}); err != nil {
+		t.Fatal(err)
+	}
+
+	activities, err := p.GetActivity(plug.TimeRange{Start: now.Add(-time.Hour), End: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, activity := range activities {
+		if activity.Source != "localgit" {
+			t.Errorf("activity %q has source %q, want localgit", activity.Title, activity.Source)
+		}
+		got = append(got, activity.Kind+" "+activity.Title+" "+activity.Branch)
+	}
+	slices.Sort(got)
+
+	want := []string{
+		"checkout api: switched branch feature",
+		"commit api: Mine feature",
+		"stash api: stashed half done feature",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GetActivity() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModuleVersion is the version and checksum of a module a binary was built with.
+type ModuleVersion struct {
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`
+}
+
+// BuildMetadata describes how a Go plugin was built. Go plugins can only be opened by
+// a daiv built with the same toolchain, platform and versions of every shared module.
+type BuildMetadata struct {
+	GoVersion string                   `json:"goVersion"`
+	Platform  string                   `json:"platform,omitempty"`
+	Modules   map[string]ModuleVersion `json:"modules"`
+}
+
+// IncompatibleError is returned when a plugin was built differently than daiv. Source
+// and Version are set for plugins built from source, which can be rebuilt.
+type IncompatibleError struct {
+	Plugin   string
+	Problems []string
+	Source   string
+	Version  string
+}
+
+func (e *IncompatibleError) Error() string {
+	var msg strings.Builder
+
+	fmt.Fprintf(&msg, "plugin %s is incompatible with this daiv build:", e.Plugin)
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&msg, "\n  - %s", problem)
+	}
+
+	if e.Source != "" {
+		reinstall := "daiv plugin install " + e.Source
+		if e.Version != "" {
+			reinstall += " " + e.Version
+		}
+		fmt.Fprintf(&msg, "\n  Rebuild it with: %s (or set plugins.autoRebuild: true to rebuild automatically)", reinstall)
+	}
+
+	return msg.String()
+}
+
+var (
+	hostMetadataOnce sync.Once
+	hostMetadata     *BuildMetadata
+)
+
+// HostBuildMetadata returns the build metadata of the running daiv binary.
+func HostBuildMetadata() *BuildMetadata {
+	hostMetadataOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		hostMetadata = newBuildMetadata(info)
+	})
+
+	return hostMetadata
+}
+
+// ReadBuildMetadata reads the build metadata embedded in a Go binary or plugin.
+func ReadBuildMetadata(path string) (*BuildMetadata, error) {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build info of %s: %w", path, err)
+	}
+
+	return newBuildMetadata(info), nil
+}
+
+func newBuildMetadata(info *debug.BuildInfo) *BuildMetadata {
+	meta := &BuildMetadata{
+		GoVersion: info.GoVersion,
+		Modules:   make(map[string]ModuleVersion),
+	}
+
+	var goos, goarch string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "GOOS":
+			goos = setting.Value
+		case "GOARCH":
+			goarch = setting.Value
+		}
+	}
+	if goos != "" && goarch != "" {
+		meta.Platform = goos + "/" + goarch
+	}
+
+	for _, dep := range info.Deps {
+		module := dep
+		if dep.Replace != nil {
+			module = dep.Replace
+		}
+		meta.Modules[dep.Path] = ModuleVersion{Version: module.Version, Sum: module.Sum}
+	}
+
+	return meta
+}
+
+// CheckCompatibility compares the plugin's build metadata with daiv's and returns an
+// *IncompatibleError listing what has to change for the plugin to load.
+func (m *BuildMetadata) CheckCompatibility(pluginName string) error {
+	host := HostBuildMetadata()
+	if host == nil {
+		return nil
+	}
+
+	var problems []string
+
+	if m.GoVersion != host.GoVersion {
+		problems = append(problems, fmt.Sprintf(
+			"built with %s but daiv was built with %s: rebuild with %s (e.g. GOTOOLCHAIN=%s go build -buildmode=plugin)",
+			m.GoVersion, host.GoVersion, host.GoVersion, host.GoVersion,
+		))
+	}
+
+	if m.Platform != "" && host.Platform != "" && m.Platform != host.Platform {
+		problems = append(problems, fmt.Sprintf("built for %s but daiv runs on %s", m.Platform, host.Platform))
+	}
+
+	var paths []string
+	for path := range m.Modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pluginModule := m.Modules[path]
+		hostModule, ok := host.Modules[path]
+		if !ok {
+			continue
+		}
+
+		if pluginModule.Version != hostModule.Version {
+			problems = append(problems, fmt.Sprintf(
+				"uses %s %s but daiv uses %s: run go get %s@%s and rebuild",
+				path, pluginModule.Version, hostModule.Version, path, hostModule.Version,
+			))
+		} else if pluginModule.Sum != "" && hostModule.Sum != "" && pluginModule.Sum != hostModule.Sum {
+			problems = append(problems, fmt.Sprintf("uses a different build of %s %s than daiv", path, pluginModule.Version))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &IncompatibleError{
+		Plugin:   pluginName,
+		Problems: problems,
+	}
+}
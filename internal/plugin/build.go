@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// releasePattern matches the Go versions of releases, which GOTOOLCHAIN accepts.
+var releasePattern = regexp.MustCompile(`^go[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// toolchain returns the GOTOOLCHAIN to build plugins with: the release daiv was built
+// with, or the local toolchain when daiv was built with a devel or custom one.
+func toolchain(goVersion string) string {
+	if releasePattern.MatchString(goVersion) {
+		return goVersion
+	}
+	return "local"
+}
+
+// buildGoPlugin builds the plugin in dir the way daiv itself was built: with the same Go
+// toolchain and the same versions of every module shared with daiv, so that the result
+// can be opened by the running daiv.
+func buildGoPlugin(dir string, output string) error {
+	host := HostBuildMetadata()
+
+	env := os.Environ()
+	if host != nil {
+		env = append(env, "GOTOOLCHAIN="+toolchain(host.GoVersion))
+
+		if err := alignDependencies(dir, env, host); err != nil {
+			return err
+		}
+	}
+
+	buildCmd := exec.Command("go", "build", "-buildmode=plugin", "-o", output)
+	buildCmd.Dir = dir
+	buildCmd.Env = env
+	buildCmd.Stdout = os.Stdout
+	buildCmd.Stderr = os.Stderr
+	if err := buildCmd.Run(); err != nil {
+		return fmt.Errorf("failed to build plugin: %w", err)
+	}
+
+	return nil
+}
+
+// alignDependencies pins the modules the plugin shares with daiv to daiv's versions.
+func alignDependencies(dir string, env []string, host *BuildMetadata) error {
+	var stdout, stderr bytes.Buffer
+
+	listCmd := exec.Command("go", "list", "-m", "-f", "{{.Path}} {{.Version}}", "all")
+	listCmd.Dir = dir
+	listCmd.Env = env
+	listCmd.Stdout = &stdout
+	listCmd.Stderr = &stderr
+	if err := listCmd.Run(); err != nil {
+		return fmt.Errorf("failed to list plugin modules: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var pins []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		path, version, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+
+		hostModule, shared := host.Modules[path]
+		if shared && hostModule.Version != "" && hostModule.Version != "(devel)" && hostModule.Version != version {
+			pins = append(pins, path+"@"+hostModule.Version)
+		}
+	}
+
+	if len(pins) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Aligning plugin dependencies with daiv: %s\n", strings.Join(pins, " "))
+
+	getCmd := exec.Command("go", append([]string{"get"}, pins...)...)
+	getCmd.Dir = dir
+	getCmd.Env = env
+	getCmd.Stdout = os.Stdout
+	getCmd.Stderr = os.Stderr
+	if err := getCmd.Run(); err != nil {
+		return fmt.Errorf("failed to align plugin dependencies: %w", err)
+	}
+
+	return nil
+}
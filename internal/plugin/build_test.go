@@ -0,0 +1,26 @@
+package plugin
+
+import "testing"
+
+func TestToolchain(t *testing.T) {
+	tests := []struct {
+		goVersion string
+		want      string
+	}{
+		{"go1.23.6", "go1.23.6"},
+		{"go1.21", "go1.21"},
+		{"go1.24.0", "go1.24.0"},
+		{"devel go1.25-a1b2c3d Tue Feb 4 10:00:00 2025 +0000", "local"},
+		{"go1.23.6 X:boringcrypto", "local"},
+		{"go1.24rc1", "local"},
+		{"go1", "local"},
+		{"1.23.6", "local"},
+		{"", "local"},
+	}
+
+	for _, test := range tests {
+		if got := toolchain(test.goVersion); got != test.want {
+			t.Errorf("toolchain(%q) = %q, want %q", test.goVersion, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"slices"
+
+	plug "github.com/iures/daivplug"
+)
+
+// capabilityReporter is implemented by plugins that declare their capabilities
+// explicitly instead of only through the interfaces they implement, such as
+// out-of-process plugins whose adapter implements every capability interface.
+type capabilityReporter interface {
+	Capabilities() []string
+}
+
+// supports reports whether a plugin provides a capability whose interface it implements.
+func supports(plugin plug.Plugin, capability string) bool {
+	reporter, ok := plugin.(capabilityReporter)
+	if !ok {
+		return true
+	}
+
+	return slices.Contains(reporter.Capabilities(), capability)
+}
+
+// capabilityInterfaces maps each capability to a check for the interface that provides it.
+var capabilityInterfaces = []struct {
+	name       string
+	implements func(plug.Plugin) bool
+}{
+	{CapabilityStandup, func(p plug.Plugin) bool { _, ok := p.(plug.StandupPlugin); return ok }},
+	{CapabilityCommands, func(p plug.Plugin) bool { _, ok := p.(CommandPlugin); return ok }},
+	{CapabilityActivity, func(p plug.Plugin) bool { _, ok := p.(ActivityPlugin); return ok }},
+	{CapabilityTickets, func(p plug.Plugin) bool { _, ok := p.(TicketPlugin); return ok }},
+	{CapabilityReviews, func(p plug.Plugin) bool { _, ok := p.(ReviewPlugin); return ok }},
+	{CapabilityBeforeCommand, func(p plug.Plugin) bool { _, ok := p.(BeforeCommandHook); return ok }},
+	{CapabilityAfterReport, func(p plug.Plugin) bool { _, ok := p.(AfterReportHook); return ok }},
+	{CapabilityOnError, func(p plug.Plugin) bool { _, ok := p.(ErrorHook); return ok }},
+}
+
+// Capabilities returns the capabilities a plugin provides.
+func Capabilities(plugin plug.Plugin) []string {
+	var capabilities []string
+	for _, capability := range capabilityInterfaces {
+		if capability.implements(plugin) && supports(plugin, capability.name) {
+			capabilities = append(capabilities, capability.name)
+		}
+	}
+	return capabilities
+}
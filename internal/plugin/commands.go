@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	plug "github.com/iures/daivplug"
+	"github.com/spf13/cobra"
+)
+
+// CommandPlugin is implemented by plugins that add subcommands to daiv. The commands
+// are mounted under the plugin's name, e.g. 'daiv jira transition'.
+//
+// Go plugins implement it with the cobra version daiv is built with, which 'daiv plugin
+// install' pins when building from source. Out-of-process plugins declare their commands
+// in describe instead, see docs/plugins/PROTOCOL.md.
+type CommandPlugin interface {
+	plug.Plugin
+	Commands() []*cobra.Command
+}
+
+// Commands returns cobra commands that run the plugin's declared commands in its process.
+// Arguments and flags are passed to the plugin as they were given.
+func (p *processPlugin) Commands() []*cobra.Command {
+	var commands []*cobra.Command
+
+	for _, command := range p.describe.Commands {
+		name := command.Name
+		use := name
+		if command.Usage != "" {
+			use += " " + command.Usage
+		}
+
+		commands = append(commands, &cobra.Command{
+			Use:                use,
+			Short:              command.Short,
+			Long:               command.Long,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var result runCommandResult
+				if err := p.call(MethodRunCommand, runCommandParams{Name: name, Args: args}, &result); err != nil {
+					return err
+				}
+
+				if result.Output != "" {
+					fmt.Fprint(cmd.OutOrStdout(), result.Output)
+					if !strings.HasSuffix(result.Output, "\n") {
+						fmt.Fprintln(cmd.OutOrStdout())
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	return commands
+}
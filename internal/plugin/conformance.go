@@ -0,0 +1,432 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+// CheckStatus is the outcome of a conformance check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckWarn CheckStatus = "WARN"
+	CheckFail CheckStatus = "FAIL"
+)
+
+// CheckResult is the result of a single conformance check.
+type CheckResult struct {
+	Name     string
+	Status   CheckStatus
+	Message  string
+	Duration time.Duration
+}
+
+// ConformanceOptions configures RunConformance.
+type ConformanceOptions struct {
+	// Settings are passed to Initialize. Fixture values derived from the manifest are
+	// used when nil.
+	Settings map[string]any
+	// OtherPlugins are the names of the other built-in and installed plugins. The name
+	// must not collide with them and dependencies are looked up among them.
+	OtherPlugins []string
+	// Installed is set when the plugin under test is installed in the plugins directory.
+	// A plugin loaded from elsewhere is usually a newer build of an installed one, so
+	// sharing its name is only a warning.
+	Installed bool
+	// Timeout limits every call into the plugin.
+	Timeout time.Duration
+}
+
+var envVarPattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// conformance collects the results of the checks run against a plugin.
+type conformance struct {
+	plugin  plug.Plugin
+	options ConformanceOptions
+	results []CheckResult
+}
+
+func (c *conformance) add(name string, status CheckStatus, duration time.Duration, format string, args ...any) {
+	c.results = append(c.results, CheckResult{
+		Name:     name,
+		Status:   status,
+		Message:  fmt.Sprintf(format, args...),
+		Duration: duration,
+	})
+}
+
+// call runs fn with the configured timeout, turning panics into errors.
+func (c *conformance) call(fn func() error) (time.Duration, error) {
+	done := make(chan error, 1)
+	start := time.Now()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panicked: %v", r)
+			}
+		}()
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-time.After(c.options.Timeout):
+		return time.Since(start), fmt.Errorf("timed out after %s", c.options.Timeout)
+	}
+}
+
+// RunConformance exercises a loaded, uninitialized plugin the way daiv uses it and
+// checks it against daiv's expectations. The plugin is shut down afterwards.
+func RunConformance(plugin plug.Plugin, options ConformanceOptions) []CheckResult {
+	if options.Timeout == 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	c := &conformance{plugin: plugin, options: options}
+
+	c.checkName()
+	manifest := c.checkManifest()
+	c.checkDependencies()
+
+	if c.checkInitialize(manifest) {
+		c.checkStandupContext()
+		c.checkActivity()
+		c.checkTicketLookup()
+		c.checkReviewItems()
+	}
+
+	c.checkShutdown()
+
+	return c.results
+}
+
+func (c *conformance) checkName() {
+	var name string
+	duration, err := c.call(func() error {
+		name = c.plugin.Name()
+		return nil
+	})
+
+	switch {
+	case err != nil:
+		c.add("Name", CheckFail, duration, "%v", err)
+		return
+	case strings.TrimSpace(name) == "":
+		c.add("Name", CheckFail, duration, "Name() returned an empty name")
+		return
+	case name != strings.TrimSpace(name) || strings.ContainsAny(name, " \t\n<>/"):
+		// The name is used as a tag around the plugin's standup context
+		c.add("Name", CheckWarn, duration, "%q contains whitespace or markup characters", name)
+		return
+	}
+
+	if slices.Contains(c.options.OtherPlugins, name) {
+		if c.options.Installed {
+			c.add("Name", CheckFail, duration, "%q is already used by another plugin", name)
+		} else {
+			c.add("Name", CheckWarn, duration, "%q is already used by an installed plugin, installing this one will conflict unless it replaces it", name)
+		}
+		return
+	}
+
+	c.add("Name", CheckPass, duration, "%q is unique", name)
+}
+
+func (c *conformance) checkManifest() *plug.PluginManifest {
+	var manifest *plug.PluginManifest
+	duration, err := c.call(func() error {
+		manifest = c.plugin.Manifest()
+		return nil
+	})
+	if err != nil {
+		c.add("Manifest", CheckFail, duration, "%v", err)
+		return nil
+	}
+	if manifest == nil {
+		c.add("Manifest", CheckFail, duration, "Manifest() returned nil")
+		return nil
+	}
+
+	var problems, warnings []string
+	keys := make(map[string]bool)
+	envVars := make(map[string]bool)
+
+	for i, key := range manifest.ConfigKeys {
+		label := fmt.Sprintf("config key %d", i)
+		if key.Key != "" {
+			label = fmt.Sprintf("config key %q", key.Key)
+		}
+
+		switch {
+		case key.Key == "":
+			problems = append(problems, label+" has no key")
+		case keys[key.Key]:
+			problems = append(problems, label+" is declared more than once")
+		case !strings.Contains(key.Key, "."):
+			warnings = append(warnings, label+" is not namespaced, e.g. \"<plugin>."+key.Key+"\"")
+		}
+		keys[key.Key] = true
+
+		if key.Type < plug.ConfigTypeString || key.Type > plug.ConfigTypeBoolean {
+			problems = append(problems, fmt.Sprintf("%s has unknown type %d", label, key.Type))
+		}
+
+		if key.Name == "" {
+			warnings = append(warnings, label+" has no display name")
+		}
+
+		if key.EnvVar != "" {
+			if !envVarPattern.MatchString(key.EnvVar) {
+				problems = append(problems, fmt.Sprintf("%s uses invalid environment variable name %q", label, key.EnvVar))
+			}
+			if envVars[key.EnvVar] {
+				problems = append(problems, fmt.Sprintf("%s reuses environment variable %s", label, key.EnvVar))
+			}
+			envVars[key.EnvVar] = true
+		}
+
+		if key.Secret && key.EnvVar == "" && key.Type != plug.ConfigTypePassword {
+			warnings = append(warnings, label+" is secret but is prompted as plain text, use ConfigTypePassword")
+		}
+	}
+
+	switch {
+	case len(problems) > 0:
+		c.add("Manifest", CheckFail, duration, "%s", strings.Join(append(problems, warnings...), "; "))
+	case len(warnings) > 0:
+		c.add("Manifest", CheckWarn, duration, "%s", strings.Join(warnings, "; "))
+	default:
+		c.add("Manifest", CheckPass, duration, "%d valid config keys", len(manifest.ConfigKeys))
+	}
+
+	return manifest
+}
+
+func (c *conformance) checkDependencies() {
+	var dependencies []string
+	duration, err := c.call(func() error {
+		dependencies = Dependencies(c.plugin)
+		return nil
+	})
+	if err != nil {
+		c.add("Dependencies", CheckFail, duration, "%v", err)
+		return
+	}
+	if len(dependencies) == 0 {
+		return
+	}
+
+	var missing []string
+	for _, dependency := range dependencies {
+		if dependency == c.plugin.Name() {
+			c.add("Dependencies", CheckFail, duration, "%q depends on itself", dependency)
+			return
+		}
+		if !slices.Contains(c.options.OtherPlugins, dependency) {
+			missing = append(missing, dependency)
+		}
+	}
+
+	if len(missing) > 0 {
+		c.add("Dependencies", CheckWarn, duration, "%s not installed, the plugin will be skipped without them", strings.Join(missing, ", "))
+		return
+	}
+
+	c.add("Dependencies", CheckPass, duration, "%s installed", strings.Join(dependencies, ", "))
+}
+
+func (c *conformance) checkInitialize(manifest *plug.PluginManifest) bool {
+	settings := c.options.Settings
+	source := "configured settings"
+	if settings == nil {
+		settings = fixtureSettings(manifest)
+		source = "fixture settings"
+	}
+
+	duration, err := c.call(func() error {
+		return c.plugin.Initialize(settings)
+	})
+	if err != nil {
+		c.add("Initialize", CheckFail, duration, "with %s: %v", source, err)
+		return false
+	}
+
+	c.add("Initialize", CheckPass, duration, "with %s", source)
+	return true
+}
+
+// fixtureSettings returns a plausible value for every config key in the manifest.
+func fixtureSettings(manifest *plug.PluginManifest) map[string]any {
+	settings := make(map[string]any)
+	if manifest == nil {
+		return settings
+	}
+
+	for _, key := range manifest.ConfigKeys {
+		switch key.Type {
+		case plug.ConfigTypeBoolean:
+			settings[key.Key] = true
+		case plug.ConfigTypeMultiline, plug.ConfigTypeMultiSelect:
+			settings[key.Key] = []string{"fixture-1", "fixture-2"}
+		default:
+			settings[key.Key] = "fixture-" + strings.ReplaceAll(key.Key, ".", "-")
+		}
+	}
+
+	return settings
+}
+
+func (c *conformance) checkStandupContext() {
+	standupPlugin, ok := c.plugin.(plug.StandupPlugin)
+	if !ok || !supports(c.plugin, CapabilityStandup) {
+		return
+	}
+
+	now := time.Now()
+	today := now.Truncate(24 * time.Hour)
+
+	ranges := []struct {
+		name      string
+		timeRange plug.TimeRange
+		wantEmpty bool
+	}{
+		{"previous day", plug.TimeRange{Start: today.AddDate(0, 0, -1), End: today.Add(-time.Nanosecond)}, false},
+		{"previous week", plug.TimeRange{Start: today.AddDate(0, 0, -7), End: today.Add(-time.Nanosecond)}, false},
+		{"empty range", plug.TimeRange{Start: now, End: now}, true},
+		{"future range", plug.TimeRange{Start: now.AddDate(1, 0, 0), End: now.AddDate(1, 0, 1)}, true},
+	}
+
+	for _, r := range ranges {
+		name := "GetStandupContext (" + r.name + ")"
+
+		var standupContext plug.StandupContext
+		duration, err := c.call(func() error {
+			var err error
+			standupContext, err = standupPlugin.GetStandupContext(r.timeRange)
+			return err
+		})
+
+		switch {
+		case err != nil:
+			c.add(name, CheckFail, duration, "%v", err)
+		case r.wantEmpty && standupContext.Content != "":
+			c.add(name, CheckWarn, duration, "returned %d bytes for a range without activity", len(standupContext.Content))
+		default:
+			c.add(name, CheckPass, duration, "returned %d bytes", len(standupContext.Content))
+		}
+	}
+}
+
+func (c *conformance) checkActivity() {
+	activityPlugin, ok := c.plugin.(ActivityPlugin)
+	if !ok || !supports(c.plugin, CapabilityActivity) {
+		return
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	timeRange := plug.TimeRange{Start: today.AddDate(0, 0, -7), End: today.Add(-time.Nanosecond)}
+
+	var activities []Activity
+	duration, err := c.call(func() error {
+		var err error
+		activities, err = activityPlugin.GetActivity(timeRange)
+		return err
+	})
+	if err != nil {
+		c.add("GetActivity", CheckFail, duration, "%v", err)
+		return
+	}
+
+	var warnings []string
+	for i, activity := range activities {
+		switch {
+		case activity.Time.Before(timeRange.Start) || activity.Time.After(timeRange.End):
+			warnings = append(warnings, fmt.Sprintf("activity %d at %s is outside the requested range", i, activity.Time.Format(time.RFC3339)))
+		case activity.Title == "":
+			warnings = append(warnings, fmt.Sprintf("activity %d has no title", i))
+		case activity.Kind == "":
+			warnings = append(warnings, fmt.Sprintf("activity %d has no kind", i))
+		}
+	}
+
+	if len(warnings) > 0 {
+		c.add("GetActivity", CheckWarn, duration, "%s", strings.Join(warnings, "; "))
+		return
+	}
+
+	c.add("GetActivity", CheckPass, duration, "returned %d activities", len(activities))
+}
+
+// unknownTicketKey is looked up to check how a TicketPlugin reports unknown tickets.
+const unknownTicketKey = "DAIVCONFORMANCE-0"
+
+func (c *conformance) checkTicketLookup() {
+	ticketPlugin, ok := c.plugin.(TicketPlugin)
+	if !ok || !supports(c.plugin, CapabilityTickets) {
+		return
+	}
+
+	var ticket *Ticket
+	duration, err := c.call(func() error {
+		var err error
+		ticket, err = ticketPlugin.LookupTicket(unknownTicketKey)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, ErrTicketNotFound):
+		c.add("LookupTicket", CheckPass, duration, "unknown key %s is not found", unknownTicketKey)
+	case err != nil:
+		c.add("LookupTicket", CheckFail, duration, "looking up unknown key %s: %v", unknownTicketKey, err)
+	case ticket == nil:
+		c.add("LookupTicket", CheckFail, duration, "returned neither a ticket nor an error for %s", unknownTicketKey)
+	default:
+		c.add("LookupTicket", CheckWarn, duration, "returned ticket %q for unknown key %s", ticket.Key, unknownTicketKey)
+	}
+}
+
+func (c *conformance) checkReviewItems() {
+	reviewPlugin, ok := c.plugin.(ReviewPlugin)
+	if !ok || !supports(c.plugin, CapabilityReviews) {
+		return
+	}
+
+	var items []ReviewItem
+	duration, err := c.call(func() error {
+		var err error
+		items, err = reviewPlugin.GetReviewItems()
+		return err
+	})
+	if err != nil {
+		c.add("GetReviewItems", CheckFail, duration, "%v", err)
+		return
+	}
+
+	for i, item := range items {
+		if item.Title == "" {
+			c.add("GetReviewItems", CheckWarn, duration, "item %d has no title", i)
+			return
+		}
+	}
+
+	c.add("GetReviewItems", CheckPass, duration, "returned %d items", len(items))
+}
+
+func (c *conformance) checkShutdown() {
+	duration, err := c.call(c.plugin.Shutdown)
+	if err != nil {
+		c.add("Shutdown", CheckFail, duration, "%v", err)
+		return
+	}
+
+	c.add("Shutdown", CheckPass, duration, "")
+}
@@ -0,0 +1,275 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	plug "github.com/iures/daivplug"
+)
+
+// devServerFile is added to the plugin's main package to turn it into an executable.
+const devServerFile = "zz_daiv_dev_server.go"
+
+// devServerTemplate serves the Plugin variable of a Go plugin over the out-of-process
+// protocol, so that it can be rebuilt and restarted without restarting daiv.
+var devServerTemplate = template.Must(template.New("server").Parse(`// Code generated by daiv plugin dev. DO NOT EDIT.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+type daivDevRequest struct {
+	ID     int64           ` + "`json:\"id\"`" + `
+	Method string          ` + "`json:\"method\"`" + `
+	Params json.RawMessage ` + "`json:\"params\"`" + `
+}
+
+type daivDevError struct {
+	Code    int    ` + "`json:\"code\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+type daivDevResponse struct {
+	JSONRPC string        ` + "`json:\"jsonrpc\"`" + `
+	ID      int64         ` + "`json:\"id\"`" + `
+	Result  any           ` + "`json:\"result,omitempty\"`" + `
+	Error   *daivDevError ` + "`json:\"error,omitempty\"`" + `
+}
+
+var daivDevPlugin plug.Plugin = Plugin
+
+func main() {
+	encoder := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var request daivDevRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			continue
+		}
+
+		result, err := daivDevHandle(request.Method, request.Params)
+		response := daivDevResponse{JSONRPC: "2.0", ID: request.ID, Result: result}
+		if err != nil {
+			response.Error = &daivDevError{Code: -32000, Message: err.Error()}
+		}
+		encoder.Encode(response)
+
+		if request.Method == "{{.Shutdown}}" {
+			return
+		}
+	}
+}
+
+func daivDevHandle(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "{{.Describe}}":
+		var keys []map[string]any
+		for _, key := range daivDevPlugin.Manifest().ConfigKeys {
+			keys = append(keys, map[string]any{
+				"type": key.Type, "key": key.Key, "value": key.Value, "name": key.Name,
+				"description": key.Description, "required": key.Required,
+				"secret": key.Secret, "envVar": key.EnvVar,
+			})
+		}
+
+		capabilities := []string{}
+		if _, ok := daivDevPlugin.(plug.StandupPlugin); ok {
+			capabilities = append(capabilities, "{{.Standup}}")
+		}
+
+		return map[string]any{
+			"protocolVersion": {{.ProtocolVersion}},
+			"name":            daivDevPlugin.Name(),
+			"version":         "dev",
+			"manifest":        map[string]any{"configKeys": keys},
+			"capabilities":    capabilities,
+		}, nil
+
+	case "{{.Initialize}}":
+		var p struct {
+			Settings map[string]any ` + "`json:\"settings\"`" + `
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return struct{}{}, daivDevPlugin.Initialize(p.Settings)
+
+	case "{{.GetStandupContext}}":
+		standupPlugin, ok := daivDevPlugin.(plug.StandupPlugin)
+		if !ok {
+			return nil, errors.New("plugin does not implement StandupPlugin")
+		}
+
+		var p struct {
+			Start string ` + "`json:\"start\"`" + `
+			End   string ` + "`json:\"end\"`" + `
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		start, err := time.Parse(time.RFC3339Nano, p.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.RFC3339Nano, p.End)
+		if err != nil {
+			return nil, err
+		}
+
+		standupContext, err := standupPlugin.GetStandupContext(plug.TimeRange{Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"content": standupContext.Content}, nil
+
+	case "{{.Shutdown}}":
+		return struct{}{}, daivDevPlugin.Shutdown()
+	}
+
+	return nil, errors.New("unknown method " + method)
+}
+`))
+
+// BuildDevPlugin builds the Go plugin in dir as an out-of-process plugin executable.
+// The sources are copied first and the plugin's main package gets a generated main
+// function serving its Plugin variable over the protocol.
+func BuildDevPlugin(dir string, output string) error {
+	if err := checkNoMain(dir); err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "daivplug-dev-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if _, err := copySourceDir(dir, tempDir); err != nil {
+		return err
+	}
+
+	server, err := os.Create(filepath.Join(tempDir, devServerFile))
+	if err != nil {
+		return fmt.Errorf("failed to create dev server: %w", err)
+	}
+	err = devServerTemplate.Execute(server, map[string]any{
+		"ProtocolVersion":   ProtocolVersion,
+		"Describe":          MethodDescribe,
+		"Initialize":        MethodInitialize,
+		"Shutdown":          MethodShutdown,
+		"GetStandupContext": MethodGetStandupContext,
+		"Standup":           CapabilityStandup,
+	})
+	server.Close()
+	if err != nil {
+		return fmt.Errorf("failed to generate dev server: %w", err)
+	}
+
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	// Out-of-process plugins don't share daiv's runtime, so any toolchain will do
+	buildCmd := exec.Command("go", "build", "-o", output, ".")
+	buildCmd.Dir = tempDir
+	buildCmd.Stdout = os.Stdout
+	buildCmd.Stderr = os.Stderr
+	if err := buildCmd.Run(); err != nil {
+		return fmt.Errorf("failed to build plugin: %w", err)
+	}
+
+	return nil
+}
+
+// checkNoMain verifies that the plugin's main package doesn't define main, which the dev server provides.
+func checkNoMain(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		if parsed.Name.Name != "main" {
+			continue
+		}
+
+		for _, decl := range parsed.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+				return fmt.Errorf("%s defines func main, which plugin dev can't run; move it behind a build tag", filepath.Base(file))
+			}
+		}
+	}
+
+	return nil
+}
+
+// OpenProcessPlugin starts an out-of-process plugin executable.
+func OpenProcessPlugin(path string) (plug.Plugin, error) {
+	p, err := startProcessPlugin(path)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SourceFingerprint returns a fingerprint of the files in a plugin source directory that
+// changes whenever a file is added, removed or modified.
+func SourceFingerprint(dir string) (string, error) {
+	hash := sha256.New()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\x00", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
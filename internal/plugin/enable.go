@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DisabledPluginsKey lists the plugins disabled with 'daiv plugin disable'.
+	DisabledPluginsKey = "plugins.disabled"
+	// OnlyPluginsKey restricts a single run to the listed plugins.
+	OnlyPluginsKey = "plugins.only"
+	// SkipPluginsKey excludes the listed plugins from a single run.
+	SkipPluginsKey = "plugins.skip"
+)
+
+// IsEnabled reports whether a plugin, known by any of the given names (its Name(), its
+// lockfile name or its file name without extension), should be loaded and used.
+func IsEnabled(names ...string) bool {
+	if isExcluded(names...) {
+		return false
+	}
+
+	only := viper.GetStringSlice(OnlyPluginsKey)
+	if len(only) == 0 {
+		return true
+	}
+
+	for _, name := range names {
+		if slices.Contains(only, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExcluded reports whether a plugin was disabled or skipped for this run under any of the given names.
+func isExcluded(names ...string) bool {
+	disabled := viper.GetStringSlice(DisabledPluginsKey)
+	skip := viper.GetStringSlice(SkipPluginsKey)
+
+	for _, name := range names {
+		if slices.Contains(disabled, name) || slices.Contains(skip, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsDisabled reports whether a plugin was disabled with 'daiv plugin disable'.
+func IsDisabled(name string) bool {
+	return slices.Contains(viper.GetStringSlice(DisabledPluginsKey), name)
+}
+
+// SetEnabled enables or disables a plugin and persists the choice in the cache config,
+// next to the plugin settings saved by Initialize.
+func SetEnabled(name string, enabled bool) error {
+	disabled := slices.DeleteFunc(viper.GetStringSlice(DisabledPluginsKey), func(n string) bool {
+		return n == name
+	})
+	if !enabled {
+		disabled = append(disabled, name)
+	}
+	slices.Sort(disabled)
+
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return err
+	}
+
+	cacheConfig := viper.New()
+	configPath := filepath.Join(cacheDir, "config.yaml")
+	cacheConfig.SetConfigFile(configPath)
+	cacheConfig.ReadInConfig()
+
+	viper.Set(DisabledPluginsKey, disabled)
+	cacheConfig.Set(DisabledPluginsKey, disabled)
+
+	return cacheConfig.WriteConfigAs(configPath)
+}
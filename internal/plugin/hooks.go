@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	plug "github.com/iures/daivplug"
+)
+
+// The hook interfaces let plugins act around daiv's commands, e.g. post the standup
+// report to chat or log a worklog entry once it is generated. They only use builtin
+// types, so Go plugins can implement them without importing daiv. Commands are named
+// by their path below daiv, such as "standup" or "jira transition".
+
+// BeforeCommandHook is implemented by plugins that run before a command. Returning an
+// error stops the command.
+type BeforeCommandHook interface {
+	plug.Plugin
+	BeforeCommand(command string, args []string) error
+}
+
+// AfterReportHook is implemented by plugins that receive the reports generated by
+// commands, such as the standup report.
+type AfterReportHook interface {
+	plug.Plugin
+	AfterReport(command string, report string) error
+}
+
+// ErrorHook is implemented by plugins that are told when a command fails.
+type ErrorHook interface {
+	plug.Plugin
+	OnError(command string, err error)
+}
+
+// RunBeforeCommand runs the BeforeCommand hooks of the registered plugins, stopping at
+// the first one that fails.
+func (r *Registry) RunBeforeCommand(command string, args []string) error {
+	for _, plugin := range r.use(implements[BeforeCommandHook](CapabilityBeforeCommand)) {
+		if err := plugin.(BeforeCommandHook).BeforeCommand(command, args); err != nil {
+			return fmt.Errorf("plugin %s: %w", plugin.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// RunAfterReport passes a report to the AfterReport hooks of the registered plugins.
+// Failing hooks don't affect the others.
+func (r *Registry) RunAfterReport(command string, report string) error {
+	var errs []error
+	for _, plugin := range r.use(implements[AfterReportHook](CapabilityAfterReport)) {
+		if err := plugin.(AfterReportHook).AfterReport(command, report); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", plugin.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunOnError tells the ErrorHook plugins that a command failed. Only plugins that are
+// already initialized are told, so a failing command doesn't initialize, and possibly
+// prompt for the settings of, plugins it didn't use.
+func (r *Registry) RunOnError(command string, err error) {
+	isHook := implements[ErrorHook](CapabilityOnError)
+	for _, plugin := range r.All() {
+		if isHook(plugin) && r.isInitialized(plugin.Name()) {
+			plugin.(ErrorHook).OnError(command, err)
+		}
+	}
+}
+
+func (p *processPlugin) BeforeCommand(command string, args []string) error {
+	if !slices.Contains(p.Capabilities(), CapabilityBeforeCommand) {
+		return nil
+	}
+	return p.call(MethodBeforeCommand, beforeCommandParams{Command: command, Args: args}, nil)
+}
+
+func (p *processPlugin) AfterReport(command string, report string) error {
+	if !slices.Contains(p.Capabilities(), CapabilityAfterReport) {
+		return nil
+	}
+	return p.call(MethodAfterReport, afterReportParams{Command: command, Report: report}, nil)
+}
+
+func (p *processPlugin) OnError(command string, err error) {
+	if !slices.Contains(p.Capabilities(), CapabilityOnError) {
+		return
+	}
+	p.call(MethodOnError, onErrorParams{Command: command, Error: err.Error()}, nil)
+}
@@ -0,0 +1,266 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	plug "github.com/iures/daivplug"
+)
+
+// PluginInfo describes a plugin for 'daiv plugin info'. Fields that come from the
+// plugin itself are empty when it failed to load, see LoadError.
+type PluginInfo struct {
+	Name         string
+	Version      string
+	File         string
+	Entry        *LockEntry
+	Quarantined  *QuarantinedPlugin
+	ConfigKeys   []ConfigKeyInfo
+	Capabilities []string
+	Dependencies []string
+	Priority     int
+	LoadError    error
+}
+
+// ConfigKeyInfo is a config key of a plugin along with its current value, masked for secrets.
+type ConfigKeyInfo struct {
+	plug.ConfigKey
+	IsSet bool
+	// Display is the value to show, empty for secrets and unset keys.
+	Display string
+}
+
+// versionReporter is implemented by plugins that know their own version, such as out-of-process plugins.
+type versionReporter interface {
+	Version() string
+}
+
+// Describe collects the information a loaded plugin reports about itself.
+func Describe(plugin plug.Plugin) PluginInfo {
+	info := PluginInfo{
+		Name:         plugin.Name(),
+		Capabilities: Capabilities(plugin),
+		Dependencies: Dependencies(plugin),
+		Priority:     Priority(plugin),
+	}
+
+	if reporter, ok := plugin.(versionReporter); ok {
+		info.Version = reporter.Version()
+	}
+
+	manifest := plugin.Manifest()
+	if manifest == nil {
+		return info
+	}
+
+	params := getConfigParams(manifest.ConfigKeys)
+	for _, key := range manifest.ConfigKeys {
+		keyInfo := ConfigKeyInfo{ConfigKey: key}
+
+		value := params[key.Key]
+		keyInfo.IsSet = value != nil && fmt.Sprint(value) != "" && fmt.Sprint(value) != "[]"
+		if keyInfo.IsSet && !isSecret(key) {
+			keyInfo.Display = fmt.Sprint(value)
+		}
+
+		info.ConfigKeys = append(info.ConfigKeys, keyInfo)
+	}
+
+	return info
+}
+
+// Inspect loads an installed plugin, looked up by name or file name, without initializing
+// or registering it, and describes it. Failing to load is reported in LoadError.
+func (pm *PluginManager) Inspect(name string) (*PluginInfo, error) {
+	located, err := pm.locate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PluginInfo{
+		Name:        name,
+		File:        located.file,
+		Entry:       located.entry,
+		Quarantined: located.quarantined,
+	}
+
+	switch {
+	case located.quarantined != nil:
+		info.LoadError = fmt.Errorf("quarantined: %s", located.quarantined.Reason)
+		return info, nil
+	case located.path == "":
+		info.LoadError = fmt.Errorf("plugin file %s is missing", located.file)
+		return info, nil
+	}
+
+	plugin, err := OpenFile(located.path)
+	if err != nil {
+		info.LoadError = err
+		return info, nil
+	}
+	defer release(plugin)
+
+	described := Describe(plugin)
+	described.File = info.File
+	described.Entry = info.Entry
+	return &described, nil
+}
+
+// InstalledPath returns the path of an installed plugin, looked up by name or file name.
+func (pm *PluginManager) InstalledPath(name string) (string, error) {
+	located, err := pm.locate(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case located.quarantined != nil:
+		return "", fmt.Errorf("plugin '%s' is quarantined: %s", name, located.quarantined.Reason)
+	case located.path == "":
+		return "", fmt.Errorf("plugin file %s is missing", located.file)
+	}
+
+	return located.path, nil
+}
+
+// InstalledNames returns the names of the installed plugins by file name, without opening
+// them: the name recorded in the lockfile, or the file name without its extension for
+// plugins copied into the plugins directory by hand. Quarantined plugins are left out.
+func (pm *PluginManager) InstalledNames() (map[string]string, error) {
+	names := make(map[string]string)
+
+	entries, err := os.ReadDir(pm.pluginsDir)
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		file := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(file, ".") {
+			continue
+		}
+
+		ext := filepath.Ext(file)
+		if ext != ".so" && ext != ".dll" {
+			info, err := entry.Info()
+			if err != nil || !IsProcessPlugin(filepath.Join(pm.pluginsDir, file), info) {
+				continue
+			}
+		}
+
+		names[file] = strings.TrimSuffix(file, ext)
+		if locked, ok := lock.FindFile(file); ok {
+			names[file] = locked.Name
+		}
+	}
+
+	return names, nil
+}
+
+// OpenFile opens a plugin file without initializing it. Go plugins are checked for
+// compatibility first, instead of letting the runtime fail on them.
+func OpenFile(path string) (plug.Plugin, error) {
+	if ext := filepath.Ext(path); ext == ".so" || ext == ".dll" {
+		if meta, err := ReadBuildMetadata(path); err == nil {
+			if err := meta.CheckCompatibility(filepath.Base(path)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return openPlugin(path)
+}
+
+// locatedPlugin is where an installed plugin was found. path is empty when the plugin
+// is quarantined or its file is missing.
+type locatedPlugin struct {
+	file        string
+	path        string
+	entry       *LockEntry
+	quarantined *QuarantinedPlugin
+}
+
+// locate finds an installed plugin by its lockfile name or file name.
+func (pm *PluginManager) locate(name string) (*locatedPlugin, error) {
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	located := &locatedPlugin{}
+
+	candidates := []string{name + ".so", name + ".dll", name, name + ".exe"}
+	if entry, ok := lock.Find(name); ok {
+		located.entry = entry
+		candidates = append([]string{entry.File}, candidates...)
+	}
+
+	quarantined, err := pm.Quarantined()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range candidates {
+		for i := range quarantined {
+			if quarantined[i].File == file {
+				located.file = file
+				located.quarantined = &quarantined[i]
+				return located, nil
+			}
+		}
+
+		path := filepath.Join(pm.pluginsDir, file)
+		stat, err := os.Stat(path)
+		if err != nil || stat.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(file)
+		if ext != ".so" && ext != ".dll" && !IsProcessPlugin(path, stat) {
+			continue
+		}
+
+		located.file = file
+		located.path = path
+		if located.entry == nil {
+			if entry, ok := lock.FindFile(file); ok {
+				located.entry = entry
+			}
+		}
+		return located, nil
+	}
+
+	if located.entry != nil {
+		located.file = located.entry.File
+		return located, nil
+	}
+
+	return nil, fmt.Errorf("plugin '%s' not found", name)
+}
+
+// isSecret reports whether the value of a config key must not be shown. Not every plugin
+// flags its credentials, so keys named like one are treated as secrets too.
+func isSecret(key plug.ConfigKey) bool {
+	if key.Secret || key.Type == plug.ConfigTypePassword {
+		return true
+	}
+
+	name := strings.ToLower(key.Key)
+	for _, word := range []string{"token", "secret", "password", "apikey", "api_key"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
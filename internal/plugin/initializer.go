@@ -238,3 +238,12 @@ func getCacheDir() (string, error) {
 	}
 	return daivDir, nil
 }
+
+// Settings returns the configured settings of a plugin, as passed to its Initialize.
+func Settings(plugin plug.Plugin) map[string]any {
+	manifest := plugin.Manifest()
+	if manifest == nil {
+		return map[string]any{}
+	}
+	return getConfigParams(manifest.ConfigKeys)
+}
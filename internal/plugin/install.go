@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// installFile copies a plugin into the plugins directory. The file is staged under a
+// hidden temporary name, validated and only then renamed into place, so an interrupted
+// or broken install never leaves a plugin behind that gets loaded on the next run.
+func (pm *PluginManager) installFile(src string, filename string) (string, error) {
+	staged, err := stageFile(src, pm.pluginsDir, filename)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(staged)
+
+	if err := validatePluginFile(staged, filename); err != nil {
+		return "", fmt.Errorf("%s is not a valid plugin: %w", filename, err)
+	}
+
+	destPath := filepath.Join(pm.pluginsDir, filename)
+	if err := os.Rename(staged, destPath); err != nil {
+		return "", fmt.Errorf("failed to install plugin file: %w", err)
+	}
+
+	// A fresh install replaces a quarantined copy
+	pm.releaseQuarantine(filename)
+
+	return destPath, nil
+}
+
+// stageFile copies src to a hidden temporary file in dir and returns its path.
+func stageFile(src string, dir string, filename string) (string, error) {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer sourceFile.Close()
+
+	info, err := sourceFile.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source file: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(dir, "."+filename+".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	// Keep the executable bit of out-of-process plugins
+	_, err = io.Copy(tempFile, sourceFile)
+	if err == nil {
+		err = tempFile.Chmod(info.Mode().Perm())
+	}
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", fmt.Errorf("failed to copy plugin file: %w", err)
+	}
+
+	return tempFile.Name(), nil
+}
+
+// validatePluginFile checks that the file at path, to be installed as filename, is a
+// loadable plugin: Go plugins must export a Plugin symbol and executables must answer
+// the describe handshake.
+func validatePluginFile(path string, filename string) error {
+	switch filepath.Ext(filename) {
+	case ".so":
+		return checkPluginSymbol(path)
+	case ".dll":
+		// Go doesn't build plugins for Windows, leave it to the runtime
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !IsProcessPlugin(path, info) {
+		return fmt.Errorf("plugin file must have .so or .dll extension or be an executable")
+	}
+
+	p, err := startProcessPlugin(path)
+	if err != nil {
+		return err
+	}
+	p.Shutdown()
+
+	return nil
+}
+
+// checkPluginSymbol verifies that a Go plugin exports the Plugin variable, without
+// loading it: a plugin can't be unloaded once opened.
+func checkPluginSymbol(path string) error {
+	var symbols []string
+
+	if file, err := elf.Open(path); err == nil {
+		defer file.Close()
+
+		dynamic, err := file.DynamicSymbols()
+		if err != nil {
+			return fmt.Errorf("failed to read symbols: %w", err)
+		}
+		for _, symbol := range dynamic {
+			if elf.ST_TYPE(symbol.Info) == elf.STT_OBJECT {
+				symbols = append(symbols, symbol.Name)
+			}
+		}
+	} else if file, err := macho.Open(path); err == nil {
+		defer file.Close()
+
+		if file.Symtab == nil {
+			return fmt.Errorf("plugin has no symbol table")
+		}
+		for _, symbol := range file.Symtab.Syms {
+			symbols = append(symbols, strings.TrimPrefix(symbol.Name, "_"))
+		}
+	} else {
+		return fmt.Errorf("not an ELF or Mach-O shared library")
+	}
+
+	// The main package of a plugin is named after its plugin path, e.g. "plugin/unnamed-<hash>.Plugin"
+	for _, name := range symbols {
+		if strings.HasSuffix(name, ".Plugin") && !strings.HasPrefix(name, "go:") && !strings.HasPrefix(name, "type:") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("plugin exports no symbol named Plugin")
+}
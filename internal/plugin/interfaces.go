@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"errors"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+// The capability interfaces below extend daivplug's with structured data, so commands can
+// sort, filter and combine what plugins report instead of passing pre-rendered text on.
+// Their types live in daiv, so Go plugins can't implement them yet; built-in and
+// out-of-process plugins can.
+
+// Activity kinds known to daiv. Plugins may report others.
+const (
+	KindCommit      = "commit"
+	KindPullRequest = "pull_request"
+	KindReview      = "review"
+	KindComment     = "comment"
+	KindTicket      = "ticket"
+)
+
+// Activity is something the user did, as reported by an ActivityPlugin.
+type Activity struct {
+	Time time.Time
+	// Source is the name of the plugin that reported the activity. Activities merged
+	// from several plugins list all of them, separated by commas.
+	Source string
+	// Kind is what the activity is about, one of the Kind constants or a plugin's own.
+	Kind  string
+	Title string
+	URL   string
+	// Branch is the branch of a commit or pull request, if any.
+	Branch string
+	// TicketKeys are the tickets the activity relates to, e.g. "PROJ-123". Keys mentioned
+	// in the title or branch are found by daiv and needn't be listed.
+	TicketKeys []string
+	// StateChange is set when the activity moved something to another state.
+	StateChange *StateChange
+}
+
+// StateChange is a transition such as a ticket moving from "To Do" to "In Progress".
+type StateChange struct {
+	From string
+	To   string
+}
+
+// ActivityPlugin is implemented by plugins that report the user's activity as events.
+type ActivityPlugin interface {
+	plug.Plugin
+	GetActivity(timeRange plug.TimeRange) ([]Activity, error)
+}
+
+// Ticket is an issue in a tracker such as Jira, as returned by a TicketPlugin.
+type Ticket struct {
+	Key         string
+	Title       string
+	Status      string
+	Assignee    string
+	URL         string
+	Description string
+}
+
+// ErrTicketNotFound is returned by TicketPlugin.LookupTicket for keys it doesn't know.
+var ErrTicketNotFound = errors.New("ticket not found")
+
+// TicketPlugin is implemented by plugins that can look up tickets by key, e.g. "PROJ-123".
+type TicketPlugin interface {
+	plug.Plugin
+	LookupTicket(key string) (*Ticket, error)
+}
+
+// ReviewItem is something waiting for the user, such as a pull request awaiting their review.
+type ReviewItem struct {
+	// Source is the name of the plugin that reported the item.
+	Source string
+	// Kind is what is awaiting the user, e.g. "pull_request" or "merge_request".
+	Kind        string
+	Title       string
+	URL         string
+	Author      string
+	RequestedAt time.Time
+}
+
+// ReviewPlugin is implemented by plugins that list the items awaiting the user.
+type ReviewPlugin interface {
+	plug.Plugin
+	GetReviewItems() ([]ReviewItem, error)
+}
@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LockfileName is the name of the file recording the installed plugins, kept in the plugins directory.
+const LockfileName = "plugins.lock"
+
+const lockfileVersion = 1
+
+// Source types of installed plugins.
+const (
+	SourceGitHub = "github"
+	SourceGit    = "git"
+	SourceURL    = "url"
+	SourceLocal  = "local"
+	SourceDir    = "dir"
+)
+
+// LockSource is where an installed plugin came from.
+type LockSource struct {
+	Type     string `json:"type"`
+	Location string `json:"location"`
+}
+
+func (s LockSource) String() string {
+	if s.Type == "" {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%s", s.Type, s.Location)
+}
+
+// LockEntry records how a plugin was installed.
+type LockEntry struct {
+	Name             string         `json:"name"`
+	File             string         `json:"file"`
+	Source           LockSource     `json:"source"`
+	RequestedVersion string         `json:"requestedVersion,omitempty"`
+	ResolvedCommit   string         `json:"resolvedCommit,omitempty"`
+	Checksum         string         `json:"checksum"`
+	InstalledAt      time.Time      `json:"installedAt"`
+	Build            *BuildMetadata `json:"build,omitempty"`
+	// SourceChecksum is the checksum of the sources of plugins built from a local directory.
+	SourceChecksum string `json:"sourceChecksum,omitempty"`
+	// SignedBy is the id of the trusted key whose signature was verified on install.
+	SignedBy string `json:"signedBy,omitempty"`
+	// ExpectedChecksum is the checksum given with --sha256 on install. Updates are
+	// verified against it too.
+	ExpectedChecksum string `json:"expectedChecksum,omitempty"`
+	// Previous is the entry replaced by the last update, kept for rollback.
+	Previous *LockEntry `json:"previous,omitempty"`
+}
+
+// DisplayVersion returns the most precise version known for the entry.
+func (e LockEntry) DisplayVersion() string {
+	version := e.RequestedVersion
+	if e.ResolvedCommit != "" {
+		commit := shortCommit(e.ResolvedCommit)
+		if version == "" {
+			return commit
+		}
+		version = fmt.Sprintf("%s (%s)", version, commit)
+	}
+	return version
+}
+
+// Lockfile is the plugins.lock file.
+type Lockfile struct {
+	Version int         `json:"version"`
+	Plugins []LockEntry `json:"plugins"`
+
+	path string
+}
+
+// ReadLockfile reads the lockfile of the plugins directory. A missing lockfile is empty.
+func ReadLockfile(pluginsDir string) (*Lockfile, error) {
+	lock := &Lockfile{
+		Version: lockfileVersion,
+		path:    filepath.Join(pluginsDir, LockfileName),
+	}
+
+	data, err := os.ReadFile(lock.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", LockfileName, err)
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", lock.path, err)
+	}
+
+	return lock, nil
+}
+
+// Save writes the lockfile, with entries sorted by name. It is written to a temporary
+// file that replaces the lockfile, so an interrupted save never leaves a partial one.
+func (l *Lockfile) Save() error {
+	sort.Slice(l.Plugins, func(i, j int) bool {
+		return l.Plugins[i].Name < l.Plugins[j].Name
+	})
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", LockfileName, err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(l.path), "."+LockfileName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(append(data, '\n'))
+	if err == nil {
+		err = tempFile.Chmod(0644)
+	}
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", LockfileName, err)
+	}
+
+	if err := os.Rename(tempFile.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", LockfileName, err)
+	}
+
+	return nil
+}
+
+// Find returns the entry for a plugin, looked up by plugin name, file name or file name
+// without extension.
+func (l *Lockfile) Find(name string) (*LockEntry, bool) {
+	for i, entry := range l.Plugins {
+		if entry.Name == name || entry.File == name || strings.TrimSuffix(entry.File, filepath.Ext(entry.File)) == name {
+			return &l.Plugins[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindFile returns the entry of an installed plugin file.
+func (l *Lockfile) FindFile(file string) (*LockEntry, bool) {
+	for i, entry := range l.Plugins {
+		if entry.File == file {
+			return &l.Plugins[i], true
+		}
+	}
+	return nil, false
+}
+
+// Put adds an entry, replacing any entry for the same file.
+func (l *Lockfile) Put(entry LockEntry) {
+	for i, existing := range l.Plugins {
+		if existing.File == entry.File {
+			l.Plugins[i] = entry
+			return
+		}
+	}
+	l.Plugins = append(l.Plugins, entry)
+}
+
+// Remove deletes the entry of a plugin file.
+func (l *Lockfile) Remove(file string) {
+	for i, entry := range l.Plugins {
+		if entry.File == file {
+			l.Plugins = append(l.Plugins[:i], l.Plugins[i+1:]...)
+			return
+		}
+	}
+}
+
+// fileChecksum returns the sha256 checksum of a file as "sha256:<hex>".
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -1,8 +1,12 @@
 package plugin
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
@@ -10,8 +14,10 @@ import (
 	"plugin"
 	pluginlib "plugin" // Go standard library plugin
 	"strings"
+	"time"
 
 	plug "github.com/iures/daivplug"
+	"github.com/spf13/viper"
 )
 
 // PluginManager handles downloading, installing, and loading plugins
@@ -41,6 +47,21 @@ func (pm *PluginManager) InstallFromGitHub(repo string, version string) error {
 	
 	username, repoName := parts[0], parts[1]
 	
+	return pm.installFromGitRepo(
+		fmt.Sprintf("https://github.com/%s/%s.git", username, repoName),
+		version,
+		LockSource{Type: SourceGitHub, Location: repo},
+	)
+}
+
+// InstallFromGit clones any git repository, e.g. over ssh or from a self-hosted host,
+// and builds and installs the plugin in it
+func (pm *PluginManager) InstallFromGit(repoURL string, version string) error {
+	return pm.installFromGitRepo(repoURL, version, LockSource{Type: SourceGit, Location: repoURL})
+}
+
+// installFromGitRepo clones a plugin repository, checks out version and builds the plugin
+func (pm *PluginManager) installFromGitRepo(repoURL string, version string, source LockSource) error {
 	// Create temp directory for cloning
 	tempDir, err := os.MkdirTemp("", "daivplug-*")
 	if err != nil {
@@ -49,7 +70,7 @@ func (pm *PluginManager) InstallFromGitHub(repo string, version string) error {
 	defer os.RemoveAll(tempDir)
 	
 	// Clone the repository
-	gitCmd := exec.Command("git", "clone", fmt.Sprintf("https://github.com/%s/%s.git", username, repoName), tempDir)
+	gitCmd := exec.Command("git", "clone", repoURL, tempDir)
 	gitCmd.Stdout = os.Stdout
 	gitCmd.Stderr = os.Stderr
 	if err := gitCmd.Run(); err != nil {
@@ -67,24 +88,168 @@ func (pm *PluginManager) InstallFromGitHub(repo string, version string) error {
 		}
 	}
 	
-	// Build the plugin
-	buildCmd := exec.Command("go", "build", "-buildmode=plugin", "-o", fmt.Sprintf("%s.so", repoName))
-	buildCmd.Dir = tempDir
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-	if err := buildCmd.Run(); err != nil {
-		return fmt.Errorf("failed to build plugin: %w", err)
+	return pm.buildAndInstall(tempDir, repoName(repoURL), LockEntry{
+		Source:           source,
+		RequestedVersion: version,
+		ResolvedCommit:   gitHead(tempDir),
+	})
+}
+
+// InstallFromSourceDir builds the plugin in a local source directory and installs it.
+// The sources are copied first, so aligning dependencies doesn't touch the author's go.mod.
+func (pm *PluginManager) InstallFromSourceDir(dir string) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve plugin directory: %w", err)
+	}
+	
+	if _, err := os.Stat(filepath.Join(absDir, "go.mod")); err != nil {
+		return fmt.Errorf("%s is not a Go module: %w", absDir, err)
+	}
+	
+	tempDir, err := os.MkdirTemp("", "daivplug-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	
+	sourceChecksum, err := copySourceDir(absDir, tempDir)
+	if err != nil {
+		return err
+	}
+	
+	return pm.buildAndInstall(tempDir, filepath.Base(absDir), LockEntry{
+		Source:         LockSource{Type: SourceDir, Location: absDir},
+		ResolvedCommit: gitHead(absDir),
+		SourceChecksum: sourceChecksum,
+	})
+}
+
+// buildAndInstall builds the Go plugin in dir with daiv's toolchain and installs it as name.so
+func (pm *PluginManager) buildAndInstall(dir string, name string, entry LockEntry) error {
+	filename := name + ".so"
+	
+	// Build the plugin with daiv's toolchain and dependency versions
+	if err := buildGoPlugin(dir, filename); err != nil {
+		return err
 	}
 	
 	// Copy the built plugin to plugins directory
-	pluginPath := filepath.Join(tempDir, fmt.Sprintf("%s.so", repoName))
-	destPath := filepath.Join(pm.pluginsDir, fmt.Sprintf("%s.so", repoName))
+	destPath, err := pm.installFile(filepath.Join(dir, filename), filename)
+	if err != nil {
+		return err
+	}
+	
+	fmt.Printf("Plugin installed to: %s\n", destPath)
+	return pm.recordInstall(destPath, entry)
+}
+
+// installFromSource reinstalls a plugin from a recorded source. Downloads and files are
+// verified against expectedChecksum, when given.
+func (pm *PluginManager) installFromSource(source LockSource, version string, expectedChecksum string) error {
+	switch source.Type {
+	case SourceGitHub:
+		return pm.InstallFromGitHub(source.Location, version)
+	case SourceGit:
+		return pm.InstallFromGit(source.Location, version)
+	case SourceDir:
+		return pm.InstallFromSourceDir(source.Location)
+	case SourceURL:
+		return pm.InstallFromURL(source.Location, expectedChecksum)
+	case SourceLocal:
+		return pm.InstallFromLocalFile(source.Location, expectedChecksum)
+	default:
+		return fmt.Errorf("unknown plugin source %q", source.Type)
+	}
+}
+
+// IsGitURL reports whether source looks like a git repository URL rather than a plugin download
+func IsGitURL(source string) bool {
+	for _, prefix := range []string{"git@", "ssh://", "git://", "git+ssh://", "git+https://", "file://"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	
+	isHTTP := strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+	return isHTTP && strings.HasSuffix(source, ".git")
+}
+
+// repoName returns the repository name of a git URL, e.g. "repo" for git@host:user/repo.git
+func repoName(repoURL string) string {
+	name := strings.TrimSuffix(strings.TrimRight(repoURL, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// gitHead returns the commit checked out in dir, or an empty string if it isn't a git checkout
+func gitHead(dir string) string {
+	revParseCmd := exec.Command("git", "rev-parse", "HEAD")
+	revParseCmd.Dir = dir
+	commit, err := revParseCmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(commit))
+}
+
+// copySourceDir copies a plugin source tree, leaving out version control data, and
+// returns a checksum of the copied files. With an empty dst, it only computes the checksum.
+// Builds of the same sources differ, since the build directory ends up in the binary, so
+// updates compare this checksum instead.
+func copySourceDir(src, dst string) (string, error) {
+	hash := sha256.New()
+	
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if dst == "" {
+				return nil
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to copy plugin sources: %w", err)
+		}
+		
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		hash.Write(data)
+		
+		if dst == "" {
+			return nil
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+	if err != nil {
+		return "", err
+	}
 	
-	return copyFile(pluginPath, destPath)
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// InstallFromURL downloads and installs a plugin from a direct URL
-func (pm *PluginManager) InstallFromURL(url string) error {
+// InstallFromURL downloads and installs a plugin from a direct URL. The download is
+// verified against expectedChecksum, when given, and the published checksum and signature.
+func (pm *PluginManager) InstallFromURL(url string, expectedChecksum string) error {
 	// Extract the filename from the URL
 	parts := strings.Split(url, "/")
 	filename := parts[len(parts)-1]
@@ -104,25 +269,39 @@ func (pm *PluginManager) InstallFromURL(url string) error {
 		return fmt.Errorf("failed to download plugin: HTTP status %d", resp.StatusCode)
 	}
 	
-	// Create the destination file
-	destPath := filepath.Join(pm.pluginsDir, filename)
-	destFile, err := os.Create(destPath)
+	// Download outside the plugins directory, so nothing unverified gets loaded
+	tempFile, err := os.CreateTemp("", "daivplug-*-"+filename)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer destFile.Close()
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
 	
-	// Copy the downloaded content to the destination file
-	if _, err := io.Copy(destFile, resp.Body); err != nil {
+	if _, err := io.Copy(tempFile, resp.Body); err != nil {
 		return fmt.Errorf("failed to save plugin file: %w", err)
 	}
 	
+	signedBy, err := verifyPlugin(tempFile.Name(), expectedChecksum, urlSibling(url))
+	if err != nil {
+		return err
+	}
+	
+	destPath, err := pm.installFile(tempFile.Name(), filename)
+	if err != nil {
+		return err
+	}
+	
 	fmt.Printf("Plugin installed to: %s\n", destPath)
-	return nil
+	return pm.recordInstall(destPath, LockEntry{
+		Source:           LockSource{Type: SourceURL, Location: url},
+		SignedBy:         signedBy,
+		ExpectedChecksum: expectedChecksum,
+	})
 }
 
-// InstallFromLocalFile copies a local plugin file to the plugins directory
-func (pm *PluginManager) InstallFromLocalFile(filePath string) error {
+// InstallFromLocalFile copies a local plugin file to the plugins directory. Like downloads,
+// the file is verified against expectedChecksum and any .sha256 and .minisig files next to it.
+func (pm *PluginManager) InstallFromLocalFile(filePath string, expectedChecksum string) error {
 	// Verify the file exists
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -133,27 +312,138 @@ func (pm *PluginManager) InstallFromLocalFile(filePath string) error {
 		return fmt.Errorf("expected a file, got a directory")
 	}
 	
-	// Check file extension
-	if !strings.HasSuffix(filePath, ".so") && !strings.HasSuffix(filePath, ".dll") {
-		return fmt.Errorf("plugin file must have .so or .dll extension")
+	// Check file extension, executables are out-of-process plugins
+	if !strings.HasSuffix(filePath, ".so") && !strings.HasSuffix(filePath, ".dll") && !IsProcessPlugin(filePath, fileInfo) {
+		return fmt.Errorf("plugin file must have .so or .dll extension or be an executable")
+	}
+	
+	signedBy, err := verifyPlugin(filePath, expectedChecksum, fileSibling(filePath))
+	if err != nil {
+		return err
 	}
 	
 	// Get the filename
 	filename := filepath.Base(filePath)
 	
-	// Create the destination path
-	destPath := filepath.Join(pm.pluginsDir, filename)
-	
 	// Copy the file
-	if err := copyFile(filePath, destPath); err != nil {
-		return fmt.Errorf("failed to copy plugin file: %w", err)
+	destPath, err := pm.installFile(filePath, filename)
+	if err != nil {
+		return err
 	}
 	
 	fmt.Printf("Plugin installed to: %s\n", destPath)
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	return pm.recordInstall(destPath, LockEntry{
+		Source:           LockSource{Type: SourceLocal, Location: absPath},
+		SignedBy:         signedBy,
+		ExpectedChecksum: expectedChecksum,
+	})
+}
+
+// recordInstall records an installed plugin in the lockfile, along with its checksum
+// and, for Go plugins, its build metadata. It warns when a Go plugin cannot be loaded
+// by this daiv build.
+func (pm *PluginManager) recordInstall(pluginPath string, entry LockEntry) error {
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	entry.File = filepath.Base(pluginPath)
+	entry.Name = strings.TrimSuffix(entry.File, filepath.Ext(entry.File))
+	entry.InstalledAt = time.Now()
+
+	entry.Checksum, err = fileChecksum(pluginPath)
+	if err != nil {
+		return fmt.Errorf("failed to compute plugin checksum: %w", err)
+	}
+
+	if info, err := os.Stat(pluginPath); err == nil && IsProcessPlugin(pluginPath, info) {
+		// Out-of-process plugins report their name on startup
+		if p, err := startProcessPlugin(pluginPath); err == nil {
+			entry.Name = p.Name()
+			p.Shutdown()
+		}
+	} else if ext := filepath.Ext(pluginPath); ext == ".so" || ext == ".dll" {
+		meta, err := ReadBuildMetadata(pluginPath)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		} else {
+			entry.Build = meta
+			if err := meta.CheckCompatibility(entry.File); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+	}
+
+	lock.Put(entry)
+
+	if err := lock.Save(); err != nil {
+		return fmt.Errorf("failed to update %s: %w", LockfileName, err)
+	}
+
 	return nil
 }
 
-// LoadPlugins loads all plugins from the plugins directory
+// Uninstall removes an installed plugin, looked up by name or file name, and its lockfile entry
+func (pm *PluginManager) Uninstall(name string) error {
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	candidates := []string{name + ".so", name + ".dll", name, name + ".exe"}
+	if entry, ok := lock.Find(name); ok {
+		candidates = append([]string{entry.File}, candidates...)
+	}
+
+	for _, file := range candidates {
+		path := filepath.Join(pm.pluginsDir, file)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(file)
+		if ext != ".so" && ext != ".dll" && !IsProcessPlugin(path, info) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove plugin: %w", err)
+		}
+		os.Remove(path + previousSuffix)
+
+		lock.Remove(file)
+		return lock.Save()
+	}
+
+	for _, file := range candidates {
+		if pm.releaseQuarantine(file) {
+			lock.Remove(file)
+			return lock.Save()
+		}
+	}
+
+	return fmt.Errorf("plugin '%s' not found", name)
+}
+
+// Lockfile returns the lockfile of the plugins directory
+func (pm *PluginManager) Lockfile() (*Lockfile, error) {
+	return ReadLockfile(pm.pluginsDir)
+}
+
+// PluginsDir returns the directory plugins are installed in
+func (pm *PluginManager) PluginsDir() string {
+	return pm.pluginsDir
+}
+
+// LoadPlugins loads all plugins from the plugins directory: Go plugins (.so/.dll)
+// are opened in-process and executables are started as out-of-process plugins
 func (pm *PluginManager) LoadPlugins() ([]plug.Plugin, error) {
 	var plugins []plug.Plugin
 	
@@ -168,38 +458,165 @@ func (pm *PluginManager) LoadPlugins() ([]plug.Plugin, error) {
 		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
 	}
 	
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return nil, err
+	}
+	
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue // Skip directories
 		}
 		
 		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue // Skip installs in progress
+		}
+		
+		// Don't start or open plugins disabled by their file or lockfile name
+		names := []string{strings.TrimSuffix(name, filepath.Ext(name))}
+		if entry, ok := lock.FindFile(name); ok {
+			names = append(names, entry.Name)
+		}
+		if isExcluded(names...) {
+			continue
+		}
+		
+		path := filepath.Join(pm.pluginsDir, name)
 		ext := filepath.Ext(name)
 		if ext != ".so" && ext != ".dll" {
-			continue // Skip non-plugin files
+			// Executables are out-of-process plugins
+			info, err := entry.Info()
+			if err != nil || !IsProcessPlugin(path, info) {
+				continue // Skip non-plugin files
+			}
+		} else if err := pm.checkCompatibility(path); err != nil {
+			// Verify the plugin was built like daiv before opening it, since a mismatch
+			// only produces a cryptic error from the runtime
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
 		}
 		
-		// Load the plugin
-		p, err := pluginlib.Open(filepath.Join(pm.pluginsDir, name))
-		if err != nil {
-			fmt.Printf("Warning: Failed to load plugin %s: %v\n", name, err)
+		p, err := openPlugin(path)
+		var incompatible *IncompatibleError
+		if errors.As(err, &incompatible) {
+			// Found by the runtime only, e.g. for plugins without build metadata. Go can't
+			// open a plugin again once it failed, so a rebuilt plugin is loaded next run
+			if err := pm.handleIncompatible(path, incompatible); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: Plugin %s was rebuilt, it is loaded from the next run\n", name)
+			}
 			continue
 		}
-		
-		// Look up the Plugin symbol
-		plug, err := lookUpSymbol[plug.Plugin](p, "Plugin")
 		if err != nil {
-			fmt.Printf("Warning: Plugin %s does not export 'Plugin' symbol: %v\n", name, err)
+			pm.reportLoadFailure(name, err)
 			continue
 		}
 		
 		// Add to the list of plugins
-		plugins = append(plugins, *plug)
+		plugins = append(plugins, p)
 	}
 	
 	return plugins, nil
 }
 
+// reportLoadFailure warns about a plugin that failed to load. Plugins that are broken
+// themselves are quarantined, so that they aren't loaded again on every run. Incompatible
+// plugins are not quarantined, they can be rebuilt in place, and neither are plugins
+// that failed for reasons that may not last, such as too many open files.
+func (pm *PluginManager) reportLoadFailure(filename string, err error) {
+	if !isInvalidPlugin(err) {
+		fmt.Fprintf(os.Stderr, "Warning: Plugin %s: %v\n", filename, err)
+		return
+	}
+
+	if qErr := pm.quarantine(filename, err); qErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Plugin %s: %v (%v)\n", filename, err, qErr)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Plugin %s: %v, moved to %s\n", filename, err, filepath.Join(pm.pluginsDir, QuarantineDirName))
+}
+
+// checkCompatibility verifies the build metadata of a Go plugin. When plugins.autoRebuild
+// is enabled, incompatible plugins installed from GitHub are rebuilt from their source.
+func (pm *PluginManager) checkCompatibility(pluginPath string) error {
+	file := filepath.Base(pluginPath)
+
+	// The metadata embedded in the binary is authoritative, even if the file was replaced by hand
+	meta, err := ReadBuildMetadata(pluginPath)
+	if err != nil {
+		// Let the runtime report what is wrong with the file
+		return nil
+	}
+
+	err = meta.CheckCompatibility(file)
+	var incompatible *IncompatibleError
+	if errors.As(err, &incompatible) {
+		return pm.handleIncompatible(pluginPath, incompatible)
+	}
+
+	return err
+}
+
+// handleIncompatible adds how to rebuild an incompatible plugin to the error, or rebuilds
+// it when plugins.autoRebuild is enabled and its source is recorded. It returns nil once
+// the plugin was rebuilt compatibly.
+func (pm *PluginManager) handleIncompatible(pluginPath string, incompatible *IncompatibleError) error {
+	file := filepath.Base(pluginPath)
+
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return incompatible
+	}
+
+	entry, ok := lock.FindFile(file)
+	if !ok || (entry.Source.Type != SourceGitHub && entry.Source.Type != SourceGit && entry.Source.Type != SourceDir) {
+		return incompatible
+	}
+
+	if !viper.GetBool("plugins.autoRebuild") {
+		incompatible.Source = entry.Source.Location
+		incompatible.Version = entry.RequestedVersion
+		return incompatible
+	}
+
+	fmt.Fprintf(os.Stderr, "Rebuilding incompatible plugin %s from %s\n", file, entry.Source.Location)
+	if err := pm.installFromSource(entry.Source, entry.RequestedVersion, ""); err != nil {
+		return fmt.Errorf("failed to rebuild plugin %s: %w", file, err)
+	}
+
+	meta, err := ReadBuildMetadata(pluginPath)
+	if err != nil {
+		return err
+	}
+
+	return meta.CheckCompatibility(file)
+}
+
+// openPlugin starts an out-of-process plugin or opens a Go plugin and looks up its Plugin symbol
+func openPlugin(path string) (plug.Plugin, error) {
+	if ext := filepath.Ext(path); ext != ".so" && ext != ".dll" {
+		p, err := startProcessPlugin(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start plugin: %w", err)
+		}
+		return p, nil
+	}
+
+	p, err := pluginlib.Open(path)
+	if err != nil {
+		return nil, classifyOpenError(filepath.Base(path), fmt.Errorf("failed to load plugin: %w", err))
+	}
+
+	symbol, err := lookUpSymbol[plug.Plugin](p, "Plugin")
+	if err != nil {
+		return nil, invalidPlugin(fmt.Errorf("plugin does not export 'Plugin' symbol: %w", err))
+	}
+
+	return *symbol, nil
+}
+
 func lookUpSymbol[M any](plugin *plugin.Plugin, symbolName string) (*M, error) {
 	symbol, err := plugin.Lookup(symbolName)
 	if err != nil {
@@ -215,24 +632,3 @@ func lookUpSymbol[M any](plugin *plugin.Plugin, symbolName string) (*M, error) {
 		return nil, fmt.Errorf("unexpected type from module symbol: %T", symbol)
 	}
 }
-
-// Helper function to copy a file
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer sourceFile.Close()
-	
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer destFile.Close()
-	
-	if _, err = io.Copy(destFile, sourceFile); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
-	}
-	
-	return nil
-}
@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	plug "github.com/iures/daivplug"
+)
+
+// daivplug's PluginManifest can't declare dependencies or a priority, so plugins declare
+// them by implementing these methods. Out-of-process plugins declare them in their manifest.
+
+// dependencyReporter is implemented by plugins that need other plugins to be initialized first.
+type dependencyReporter interface {
+	Dependencies() []string
+}
+
+// priorityReporter is implemented by plugins that want their context placed earlier in
+// the prompt. Higher priorities come first, the default is 0.
+type priorityReporter interface {
+	Priority() int
+}
+
+// Dependencies returns the names of the plugins a plugin depends on.
+func Dependencies(plugin plug.Plugin) []string {
+	if reporter, ok := plugin.(dependencyReporter); ok {
+		return reporter.Dependencies()
+	}
+	return nil
+}
+
+// Priority returns the priority of a plugin.
+func Priority(plugin plug.Plugin) int {
+	if reporter, ok := plugin.(priorityReporter); ok {
+		return reporter.Priority()
+	}
+	return 0
+}
+
+// resolveOrder orders plugins so that each comes after its dependencies, then by
+// descending priority, then by name, so the order is the same on every run. Plugins
+// whose dependencies are missing or form a cycle can't be ordered and are returned in
+// skipped with the reason. Plugins in available count as present dependencies.
+func resolveOrder(plugins []plug.Plugin, available map[string]bool) ([]plug.Plugin, map[string]error) {
+	byName := make(map[string]plug.Plugin, len(plugins))
+	for _, plugin := range plugins {
+		byName[plugin.Name()] = plugin
+	}
+
+	skipped := make(map[string]error)
+
+	// Skip the plugins with missing dependencies, and in turn the ones depending on them
+	for changed := true; changed; {
+		changed = false
+		for name, plugin := range byName {
+			for _, dependency := range Dependencies(plugin) {
+				if _, ok := byName[dependency]; ok || available[dependency] {
+					continue
+				}
+
+				if reason, ok := skipped[dependency]; ok {
+					skipped[name] = fmt.Errorf("depends on %s, which was skipped: %w", dependency, reason)
+				} else {
+					skipped[name] = fmt.Errorf("depends on %s, which is not installed or disabled", dependency)
+				}
+				delete(byName, name)
+				changed = true
+				break
+			}
+		}
+	}
+
+	// Kahn's algorithm, taking the ready plugins by priority and name
+	pending := make(map[string]int)
+	dependents := make(map[string][]string)
+	for name, plugin := range byName {
+		for _, dependency := range slices.Compact(slices.Sorted(slices.Values(Dependencies(plugin)))) {
+			if _, ok := byName[dependency]; ok {
+				pending[name]++
+				dependents[dependency] = append(dependents[dependency], name)
+			}
+		}
+	}
+
+	var ready []plug.Plugin
+	for name, plugin := range byName {
+		if pending[name] == 0 {
+			ready = append(ready, plugin)
+		}
+	}
+
+	ordered := make([]plug.Plugin, 0, len(byName))
+	for len(ready) > 0 {
+		slices.SortFunc(ready, comparePlugins)
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, next)
+
+		for _, dependent := range dependents[next.Name()] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, byName[dependent])
+			}
+		}
+	}
+
+	// Whatever is left is part of a cycle or depends on one
+	if len(ordered) < len(byName) {
+		for name := range byName {
+			if pending[name] > 0 {
+				skipped[name] = fmt.Errorf("dependency cycle: %s", findCycle(name, byName, pending))
+			}
+		}
+	}
+
+	return ordered, skipped
+}
+
+// comparePlugins orders plugins by descending priority, then by name.
+func comparePlugins(a, b plug.Plugin) int {
+	if c := cmp.Compare(Priority(b), Priority(a)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Name(), b.Name())
+}
+
+// findCycle follows the unresolved dependencies from a plugin until one repeats and
+// returns the cycle, e.g. "a -> b -> a".
+func findCycle(start string, byName map[string]plug.Plugin, pending map[string]int) string {
+	var path []string
+	seen := make(map[string]int)
+
+	for name := start; ; {
+		if i, ok := seen[name]; ok {
+			return strings.Join(append(path[i:], name), " -> ")
+		}
+		seen[name] = len(path)
+		path = append(path, name)
+
+		dependencies := slices.Sorted(slices.Values(Dependencies(byName[name])))
+		for _, dependency := range dependencies {
+			if pending[dependency] > 0 {
+				name = dependency
+				break
+			}
+		}
+	}
+}
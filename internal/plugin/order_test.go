@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	plug "github.com/iures/daivplug"
+)
+
+func TestResolveOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		plugins     []*fakePlugin
+		available   map[string]bool
+		wantOrder   []string
+		wantSkipped map[string]string
+	}{
+		{
+			name:      "priority ties are ordered by name",
+			plugins:   []*fakePlugin{{name: "gamma", priority: 5}, {name: "beta"}, {name: "alpha", priority: 5}, {name: "delta"}},
+			wantOrder: []string{"alpha", "gamma", "beta", "delta"},
+		},
+		{
+			name:      "negative priorities come last",
+			plugins:   []*fakePlugin{{name: "late", priority: -1}, {name: "default"}, {name: "early", priority: 1}},
+			wantOrder: []string{"early", "default", "late"},
+		},
+		{
+			name: "dependencies come first regardless of priority",
+			plugins: []*fakePlugin{
+				{name: "report", priority: 10, dependencies: []string{"auth"}},
+				{name: "auth", priority: -5},
+				{name: "other"},
+			},
+			wantOrder: []string{"other", "auth", "report"},
+		},
+		{
+			name: "ready dependents compete by priority",
+			plugins: []*fakePlugin{
+				{name: "base"},
+				{name: "low", dependencies: []string{"base"}},
+				{name: "high", priority: 1, dependencies: []string{"base"}},
+				{name: "standalone"},
+			},
+			wantOrder: []string{"base", "high", "low", "standalone"},
+		},
+		{
+			name:      "duplicate dependencies",
+			plugins:   []*fakePlugin{{name: "b", dependencies: []string{"a", "a"}}, {name: "a"}},
+			wantOrder: []string{"a", "b"},
+		},
+		{
+			name: "missing dependencies skip their dependents",
+			plugins: []*fakePlugin{
+				{name: "orphan", dependencies: []string{"missing"}},
+				{name: "child", dependencies: []string{"orphan"}},
+				{name: "grandchild", dependencies: []string{"child"}},
+				{name: "fine"},
+			},
+			wantOrder: []string{"fine"},
+			wantSkipped: map[string]string{
+				"orphan":     "depends on missing, which is not installed or disabled",
+				"child":      "depends on orphan, which was skipped: depends on missing, which is not installed or disabled",
+				"grandchild": "depends on child, which was skipped: depends on orphan, which was skipped: depends on missing, which is not installed or disabled",
+			},
+		},
+		{
+			name:      "available dependencies",
+			plugins:   []*fakePlugin{{name: "dependent", dependencies: []string{"initialized"}}},
+			available: map[string]bool{"initialized": true},
+			wantOrder: []string{"dependent"},
+		},
+		{
+			name: "cycle",
+			plugins: []*fakePlugin{
+				{name: "a", dependencies: []string{"b"}},
+				{name: "b", dependencies: []string{"a"}},
+				{name: "c"},
+			},
+			wantOrder: []string{"c"},
+			wantSkipped: map[string]string{
+				"a": "dependency cycle: a -> b -> a",
+				"b": "dependency cycle: b -> a -> b",
+			},
+		},
+		{
+			name: "plugins depending on a cycle",
+			plugins: []*fakePlugin{
+				{name: "a", dependencies: []string{"b"}},
+				{name: "b", dependencies: []string{"c"}},
+				{name: "c", dependencies: []string{"a"}},
+				{name: "d", dependencies: []string{"c", "ok"}},
+				{name: "ok"},
+			},
+			wantOrder: []string{"ok"},
+			wantSkipped: map[string]string{
+				"a": "dependency cycle: a -> b -> c -> a",
+				"b": "dependency cycle: b -> c -> a -> b",
+				"c": "dependency cycle: c -> a -> b -> c",
+				"d": "dependency cycle: c -> a -> b -> c",
+			},
+		},
+		{
+			name:      "self dependency",
+			plugins:   []*fakePlugin{{name: "self", dependencies: []string{"self"}}},
+			wantOrder: []string{},
+			wantSkipped: map[string]string{
+				"self": "dependency cycle: self -> self",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plugins := make([]plug.Plugin, len(test.plugins))
+			for i, plugin := range test.plugins {
+				plugins[i] = plugin
+			}
+
+			// The order mustn't depend on map iteration
+			for i := 0; i < 10; i++ {
+				ordered, skipped := resolveOrder(plugins, test.available)
+
+				var names []string
+				for _, plugin := range ordered {
+					names = append(names, plugin.Name())
+				}
+				if !slices.Equal(names, test.wantOrder) && !(len(names) == 0 && len(test.wantOrder) == 0) {
+					t.Fatalf("resolveOrder() order = %v, want %v", names, test.wantOrder)
+				}
+
+				reasons := make(map[string]string)
+				for name, err := range skipped {
+					reasons[name] = err.Error()
+				}
+				if !maps.Equal(reasons, test.wantSkipped) && !(len(reasons) == 0 && len(test.wantSkipped) == 0) {
+					t.Fatalf("resolveOrder() skipped = %v, want %v", reasons, test.wantSkipped)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+const (
+	processCallTimeout     = 2 * time.Minute
+	processShutdownTimeout = 5 * time.Second
+)
+
+var (
+	errProcessExited = errors.New("plugin process exited")
+	errCallTimeout   = errors.New("plugin did not respond")
+)
+
+// processPlugin adapts a plugin running as a separate executable, speaking JSON-RPC
+// over its stdin and stdout, to the Plugin interfaces. It implements every capability
+// interface and reports the ones the plugin actually supports through Capabilities,
+// so a crashing plugin only fails its own calls instead of taking down daiv.
+type processPlugin struct {
+	path string
+	cmd  *exec.Cmd
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcResponse
+	exited  chan struct{}
+	exitErr error
+
+	describe describeResult
+}
+
+// IsProcessPlugin reports whether the file looks like an out-of-process plugin executable.
+func IsProcessPlugin(path string, info os.FileInfo) bool {
+	// Versions kept for rollback are never loaded
+	if !info.Mode().IsRegular() || strings.HasSuffix(path, previousSuffix) {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Ext(path) == ".exe"
+	}
+
+	return info.Mode().Perm()&0111 != 0
+}
+
+// startProcessPlugin starts the plugin executable and performs the describe handshake.
+func startProcessPlugin(path string) (*processPlugin, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		err = fmt.Errorf("failed to start plugin: %w", err)
+		if errors.Is(err, syscall.ENOEXEC) {
+			err = invalidPlugin(err)
+		}
+		return nil, err
+	}
+
+	p := &processPlugin{
+		path:    path,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcResponse),
+		exited:  make(chan struct{}),
+	}
+
+	go p.readResponses(stdout)
+
+	// A plugin that answers the handshake wrongly, or exits instead, is broken. One that
+	// doesn't answer in time may only be slow on a busy machine.
+	if err := p.call(MethodDescribe, nil, &p.describe); err != nil {
+		p.kill()
+		err = fmt.Errorf("describe failed: %w", err)
+		if !errors.Is(err, errCallTimeout) {
+			err = invalidPlugin(err)
+		}
+		return nil, err
+	}
+
+	if p.describe.ProtocolVersion != ProtocolVersion {
+		p.kill()
+		return nil, invalidPlugin(fmt.Errorf("plugin speaks protocol version %d, daiv expects %d", p.describe.ProtocolVersion, ProtocolVersion))
+	}
+
+	if p.describe.Name == "" {
+		p.kill()
+		return nil, invalidPlugin(fmt.Errorf("plugin did not report a name"))
+	}
+
+	return p, nil
+}
+
+// readResponses dispatches responses to the pending calls until the process closes stdout.
+func (p *processPlugin) readResponses(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: plugin %s wrote invalid response: %v\n", filepath.Base(p.path), err)
+			continue
+		}
+
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+
+		if ok {
+			ch <- resp
+		}
+	}
+
+	p.mu.Lock()
+	p.exitErr = errProcessExited
+	if err := scanner.Err(); err != nil {
+		p.exitErr = fmt.Errorf("%w: %v", errProcessExited, err)
+	}
+	p.mu.Unlock()
+	close(p.exited)
+
+	p.cmd.Wait()
+}
+
+// call sends a request and decodes the result into result, if not nil.
+func (p *processPlugin) call(method string, params any, result any) error {
+	p.mu.Lock()
+	if p.exitErr != nil {
+		err := p.exitErr
+		p.mu.Unlock()
+		return err
+	}
+	p.nextID++
+	id := p.nextID
+	ch := make(chan rpcResponse, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+
+	data, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		p.forget(id)
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	p.writeMu.Lock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		p.forget(id)
+		return fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %w", method, resp.Error)
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to decode %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-p.exited:
+		return p.exitErr
+	case <-time.After(processCallTimeout):
+		p.forget(id)
+		return fmt.Errorf("%s: %w within %s", method, errCallTimeout, processCallTimeout)
+	}
+}
+
+// forget stops waiting for the response to a request that failed.
+func (p *processPlugin) forget(id int64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+func (p *processPlugin) kill() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// Capabilities returns the capabilities declared by the plugin.
+func (p *processPlugin) Capabilities() []string {
+	return p.describe.Capabilities
+}
+
+// Version returns the version reported by the plugin, if any.
+func (p *processPlugin) Version() string {
+	return p.describe.Version
+}
+
+// Dependencies returns the plugins declared as dependencies in the manifest.
+func (p *processPlugin) Dependencies() []string {
+	return p.describe.Manifest.Dependencies
+}
+
+// Priority returns the priority declared in the manifest.
+func (p *processPlugin) Priority() int {
+	return p.describe.Manifest.Priority
+}
+
+func (p *processPlugin) Name() string {
+	return p.describe.Name
+}
+
+func (p *processPlugin) Manifest() *plug.PluginManifest {
+	return p.describe.Manifest.toManifest()
+}
+
+func (p *processPlugin) Initialize(settings map[string]interface{}) error {
+	return p.call(MethodInitialize, initializeParams{Settings: settings}, nil)
+}
+
+// Shutdown asks the plugin to clean up, then closes its stdin and waits for it to
+// exit, killing it if it does not.
+func (p *processPlugin) Shutdown() error {
+	err := p.call(MethodShutdown, nil, nil)
+	if errors.Is(err, errProcessExited) {
+		err = nil
+	}
+
+	p.stdin.Close()
+
+	select {
+	case <-p.exited:
+	case <-time.After(processShutdownTimeout):
+		p.cmd.Process.Kill()
+	}
+
+	return err
+}
+
+func (p *processPlugin) GetStandupContext(timeRange plug.TimeRange) (plug.StandupContext, error) {
+	if !slices.Contains(p.Capabilities(), CapabilityStandup) {
+		return plug.StandupContext{}, fmt.Errorf("plugin %s does not provide standup context", p.Name())
+	}
+
+	var result standupContextResult
+	err := p.call(MethodGetStandupContext, timeRangeParams{
+		Start: timeRange.Start.Format(time.RFC3339Nano),
+		End:   timeRange.End.Format(time.RFC3339Nano),
+	}, &result)
+	if err != nil {
+		return plug.StandupContext{}, err
+	}
+
+	return plug.StandupContext{
+		PluginName: p.Name(),
+		Content:    result.Content,
+	}, nil
+}
+
+func (p *processPlugin) GetActivity(timeRange plug.TimeRange) ([]Activity, error) {
+	if !slices.Contains(p.Capabilities(), CapabilityActivity) {
+		return nil, fmt.Errorf("plugin %s does not report activity", p.Name())
+	}
+
+	var result activityResult
+	err := p.call(MethodGetActivity, timeRangeParams{
+		Start: timeRange.Start.Format(time.RFC3339Nano),
+		End:   timeRange.End.Format(time.RFC3339Nano),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := make([]Activity, 0, len(result.Activities))
+	for _, activity := range result.Activities {
+		converted := Activity{
+			Time:       activity.Time,
+			Source:     p.Name(),
+			Kind:       activity.Kind,
+			Title:      activity.Title,
+			URL:        activity.URL,
+			Branch:     activity.Branch,
+			TicketKeys: activity.TicketKeys,
+		}
+		if activity.StateChange != nil {
+			converted.StateChange = &StateChange{From: activity.StateChange.From, To: activity.StateChange.To}
+		}
+		activities = append(activities, converted)
+	}
+
+	return activities, nil
+}
+
+func (p *processPlugin) LookupTicket(key string) (*Ticket, error) {
+	if !slices.Contains(p.Capabilities(), CapabilityTickets) {
+		return nil, fmt.Errorf("plugin %s does not look up tickets", p.Name())
+	}
+
+	var result lookupTicketResult
+	if err := p.call(MethodLookupTicket, lookupTicketParams{Key: key}, &result); err != nil {
+		return nil, err
+	}
+	if result.Ticket == nil {
+		return nil, ErrTicketNotFound
+	}
+
+	return &Ticket{
+		Key:         result.Ticket.Key,
+		Title:       result.Ticket.Title,
+		Status:      result.Ticket.Status,
+		Assignee:    result.Ticket.Assignee,
+		URL:         result.Ticket.URL,
+		Description: result.Ticket.Description,
+	}, nil
+}
+
+func (p *processPlugin) GetReviewItems() ([]ReviewItem, error) {
+	if !slices.Contains(p.Capabilities(), CapabilityReviews) {
+		return nil, fmt.Errorf("plugin %s does not list review items", p.Name())
+	}
+
+	var result reviewItemsResult
+	if err := p.call(MethodGetReviewItems, nil, &result); err != nil {
+		return nil, err
+	}
+
+	items := make([]ReviewItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		items = append(items, ReviewItem{
+			Source:      p.Name(),
+			Kind:        item.Kind,
+			Title:       item.Title,
+			URL:         item.URL,
+			Author:      item.Author,
+			RequestedAt: item.RequestedAt,
+		})
+	}
+
+	return items, nil
+}
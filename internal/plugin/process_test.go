@@ -0,0 +1,40 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingWriter fails every write, like the stdin of a plugin that closed it.
+type failingWriter struct{}
+
+func (failingWriter) Write(data []byte) (int, error) { return 0, errors.New("broken pipe") }
+func (failingWriter) Close() error                   { return nil }
+
+func TestProcessPluginCallForgetsFailedRequests(t *testing.T) {
+	p := &processPlugin{
+		path:    "daiv-test",
+		stdin:   failingWriter{},
+		pending: make(map[int64]chan rpcResponse),
+		exited:  make(chan struct{}),
+	}
+
+	tests := []struct {
+		name   string
+		params any
+	}{
+		{"write fails", map[string]string{"key": "value"}},
+		{"encoding fails", map[string]any{"key": make(chan int)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := p.call("test.method", test.params, nil); err == nil {
+				t.Fatal("call() succeeded, want an error")
+			}
+			if len(p.pending) != 0 {
+				t.Errorf("%d requests are still pending after the failed call", len(p.pending))
+			}
+		})
+	}
+}
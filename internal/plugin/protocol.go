@@ -0,0 +1,202 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+// ProtocolVersion is the version of the out-of-process plugin protocol spoken by daiv.
+// See docs/plugins/PROTOCOL.md for the wire format.
+const ProtocolVersion = 1
+
+// Protocol methods.
+const (
+	MethodDescribe          = "describe"
+	MethodInitialize        = "initialize"
+	MethodShutdown          = "shutdown"
+	MethodGetStandupContext = "standup.getContext"
+	MethodRunCommand        = "command.run"
+	MethodGetActivity       = "activity.list"
+	MethodLookupTicket      = "ticket.lookup"
+	MethodGetReviewItems    = "review.list"
+	MethodBeforeCommand     = "hook.beforeCommand"
+	MethodAfterReport       = "hook.afterReport"
+	MethodOnError           = "hook.onError"
+)
+
+// Capabilities a plugin can declare.
+const (
+	CapabilityStandup  = "standup"
+	CapabilityCommands = "commands"
+	CapabilityActivity = "activity"
+	CapabilityTickets  = "tickets"
+	CapabilityReviews  = "reviews"
+
+	CapabilityBeforeCommand = "beforeCommand"
+	CapabilityAfterReport   = "afterReport"
+	CapabilityOnError       = "onError"
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// describeResult is returned by the describe method.
+type describeResult struct {
+	ProtocolVersion int           `json:"protocolVersion"`
+	Name            string        `json:"name"`
+	Version         string        `json:"version,omitempty"`
+	Manifest        wireManifest  `json:"manifest"`
+	Capabilities    []string      `json:"capabilities"`
+	Commands        []wireCommand `json:"commands,omitempty"`
+}
+
+// wireCommand describes a subcommand of a plugin with the commands capability.
+type wireCommand struct {
+	Name  string `json:"name"`
+	Usage string `json:"usage,omitempty"`
+	Short string `json:"short,omitempty"`
+	Long  string `json:"long,omitempty"`
+}
+
+type wireManifest struct {
+	ConfigKeys   []wireConfigKey `json:"configKeys"`
+	Dependencies []string        `json:"dependencies,omitempty"`
+	Priority     int             `json:"priority,omitempty"`
+}
+
+type wireConfigKey struct {
+	Type        plug.ConfigType `json:"type"`
+	Key         string          `json:"key"`
+	Value       any             `json:"value,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Secret      bool            `json:"secret,omitempty"`
+	EnvVar      string          `json:"envVar,omitempty"`
+}
+
+func (m wireManifest) toManifest() *plug.PluginManifest {
+	manifest := &plug.PluginManifest{}
+	for _, key := range m.ConfigKeys {
+		manifest.ConfigKeys = append(manifest.ConfigKeys, plug.ConfigKey{
+			Type:        key.Type,
+			Key:         key.Key,
+			Value:       key.Value,
+			Name:        key.Name,
+			Description: key.Description,
+			Required:    key.Required,
+			Secret:      key.Secret,
+			EnvVar:      key.EnvVar,
+		})
+	}
+	return manifest
+}
+
+type initializeParams struct {
+	Settings map[string]any `json:"settings"`
+}
+
+type timeRangeParams struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type standupContextResult struct {
+	Content string `json:"content"`
+}
+
+type runCommandParams struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+}
+
+type runCommandResult struct {
+	Output string `json:"output"`
+}
+
+type wireActivity struct {
+	Time        time.Time        `json:"time"`
+	Kind        string           `json:"kind"`
+	Title       string           `json:"title"`
+	URL         string           `json:"url,omitempty"`
+	Branch      string           `json:"branch,omitempty"`
+	TicketKeys  []string         `json:"ticketKeys,omitempty"`
+	StateChange *wireStateChange `json:"stateChange,omitempty"`
+}
+
+type wireStateChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type activityResult struct {
+	Activities []wireActivity `json:"activities"`
+}
+
+type lookupTicketParams struct {
+	Key string `json:"key"`
+}
+
+type wireTicket struct {
+	Key         string `json:"key"`
+	Title       string `json:"title"`
+	Status      string `json:"status,omitempty"`
+	Assignee    string `json:"assignee,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type lookupTicketResult struct {
+	// Ticket is null when the plugin doesn't know the key.
+	Ticket *wireTicket `json:"ticket"`
+}
+
+type wireReviewItem struct {
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	RequestedAt time.Time `json:"requestedAt,omitempty"`
+}
+
+type reviewItemsResult struct {
+	Items []wireReviewItem `json:"items"`
+}
+
+type beforeCommandParams struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+type afterReportParams struct {
+	Command string `json:"command"`
+	Report  string `json:"report"`
+}
+
+type onErrorParams struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+}
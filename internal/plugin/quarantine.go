@@ -0,0 +1,136 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// QuarantineDirName is the directory in the plugins directory that plugins which
+	// failed to load are moved to.
+	QuarantineDirName = "quarantine"
+
+	reasonSuffix = ".reason"
+)
+
+// invalidPluginError is a load failure caused by the plugin file itself: it isn't a plugin,
+// was built differently from daiv or doesn't follow the protocol. Only these failures are
+// quarantined, others such as running out of file descriptors may not happen again.
+type invalidPluginError struct {
+	err error
+}
+
+func (e *invalidPluginError) Error() string { return e.err.Error() }
+func (e *invalidPluginError) Unwrap() error { return e.err }
+
+// invalidPlugin marks err as caused by the plugin file.
+func invalidPlugin(err error) error {
+	return &invalidPluginError{err: err}
+}
+
+// isInvalidPlugin reports whether a load failure was caused by the plugin file.
+func isInvalidPlugin(err error) bool {
+	var invalid *invalidPluginError
+	return errors.As(err, &invalid)
+}
+
+// invalidGoPluginErrors are parts of the errors of opening Go plugins that are caused by
+// the file, as opposed to the environment.
+var invalidGoPluginErrors = []string{
+	"invalid ELF header",
+	"wrong ELF class",
+	"file too short",
+	"not a dynamic",
+	"undefined symbol",
+	"not a mach-o file",
+	"slice bounds out of range",
+}
+
+// incompatibleGoPluginError is part of the error of opening a Go plugin that was built
+// against other versions of daiv's dependencies. The plugin itself is fine and can be rebuilt.
+const incompatibleGoPluginError = "different version of package"
+
+// classifyOpenError marks the errors of opening the Go plugin file that are caused by the
+// file, and reports plugins built against other dependency versions as incompatible.
+func classifyOpenError(file string, err error) error {
+	if strings.Contains(err.Error(), incompatibleGoPluginError) {
+		return &IncompatibleError{Plugin: file, Problems: []string{err.Error()}}
+	}
+
+	for _, message := range invalidGoPluginErrors {
+		if strings.Contains(err.Error(), message) {
+			return invalidPlugin(err)
+		}
+	}
+	return err
+}
+
+// QuarantinedPlugin is a plugin that was moved out of the way because it failed to load.
+type QuarantinedPlugin struct {
+	File          string
+	Reason        string
+	QuarantinedAt time.Time
+}
+
+func (pm *PluginManager) quarantineDir() string {
+	return filepath.Join(pm.pluginsDir, QuarantineDirName)
+}
+
+// quarantine moves a plugin that failed to load into the quarantine directory, along with
+// the reason, so that it isn't loaded again until it is reinstalled.
+func (pm *PluginManager) quarantine(filename string, reason error) error {
+	if err := os.MkdirAll(pm.quarantineDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	path := filepath.Join(pm.quarantineDir(), filename)
+	if err := os.Rename(filepath.Join(pm.pluginsDir, filename), path); err != nil {
+		return fmt.Errorf("failed to quarantine plugin %s: %w", filename, err)
+	}
+
+	return os.WriteFile(path+reasonSuffix, []byte(reason.Error()+"\n"), 0644)
+}
+
+// releaseQuarantine removes the quarantined copy of a plugin, if any.
+func (pm *PluginManager) releaseQuarantine(filename string) bool {
+	path := filepath.Join(pm.quarantineDir(), filename)
+	os.Remove(path + reasonSuffix)
+	return os.Remove(path) == nil
+}
+
+// Quarantined returns the plugins that were quarantined because they failed to load.
+func (pm *PluginManager) Quarantined() ([]QuarantinedPlugin, error) {
+	entries, err := os.ReadDir(pm.quarantineDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine directory: %w", err)
+	}
+
+	var quarantined []QuarantinedPlugin
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), reasonSuffix) {
+			continue
+		}
+
+		plugin := QuarantinedPlugin{File: entry.Name()}
+
+		reasonPath := filepath.Join(pm.quarantineDir(), entry.Name()+reasonSuffix)
+		if reason, err := os.ReadFile(reasonPath); err == nil {
+			plugin.Reason = strings.TrimSpace(string(reason))
+		}
+		if info, err := os.Stat(reasonPath); err == nil {
+			plugin.QuarantinedAt = info.ModTime()
+		}
+
+		quarantined = append(quarantined, plugin)
+	}
+
+	return quarantined, nil
+}
@@ -0,0 +1,186 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestLoadPluginsQuarantinesInvalidPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process plugins are started through their shebang")
+	}
+
+	pluginsDir := t.TempDir()
+	for name, content := range map[string]string{
+		"daiv-garbage": "\x00\x01\x02 not an executable",
+		"daiv-exits":   "#!/bin/sh\nexit 1\n",
+		"daiv-old":     "#!/bin/sh\nread request\necho '{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"protocolVersion\":0}}'\n",
+	} {
+		if err := os.WriteFile(filepath.Join(pluginsDir, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pm, err := NewPluginManager(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := pm.LoadPlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 0 {
+		t.Errorf("LoadPlugins() loaded %d plugins, want none", len(plugins))
+	}
+
+	quarantined, err := pm.Quarantined()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, plugin := range quarantined {
+		files = append(files, plugin.File)
+		if plugin.Reason == "" {
+			t.Errorf("%s was quarantined without a reason", plugin.File)
+		}
+	}
+	if strings.Join(files, ",") != "daiv-exits,daiv-garbage,daiv-old" {
+		t.Errorf("quarantined %v, want every plugin", files)
+	}
+}
+
+func TestReportLoadFailure(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		quarantined bool
+	}{
+		{"invalid plugin", invalidPlugin(errors.New("plugin did not report a name")), true},
+		{"wrapped invalid plugin", fmt.Errorf("loading: %w", invalidPlugin(errors.New("bad"))), true},
+		{"too many open files", fmt.Errorf("failed to start plugin: %w", syscall.EMFILE), false},
+		{"permission denied", fmt.Errorf("failed to start plugin: %w", os.ErrPermission), false},
+		{"timeout", fmt.Errorf("describe failed: %w", errCallTimeout), false},
+		{"incompatible", &IncompatibleError{Plugin: "daiv-test", Problems: []string{"different version of package"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pluginsDir := t.TempDir()
+			path := filepath.Join(pluginsDir, "daiv-test")
+			if err := os.WriteFile(path, []byte("plugin"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			pm, err := NewPluginManager(pluginsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pm.reportLoadFailure("daiv-test", test.err)
+
+			_, err = os.Stat(path)
+			if moved := errors.Is(err, os.ErrNotExist); moved != test.quarantined {
+				t.Errorf("plugin quarantined = %v, want %v", moved, test.quarantined)
+			}
+		})
+	}
+}
+
+func TestClassifyOpenError(t *testing.T) {
+	tests := []struct {
+		message      string
+		invalid      bool
+		incompatible bool
+	}{
+		{"plugin.Open(\"x\"): plugin was built with a different version of package github.com/spf13/cobra", false, true},
+		{"plugin.Open(\"x\"): x.so: invalid ELF header", true, false},
+		{"plugin.Open(\"x\"): x.so: wrong ELF class: ELFCLASS32", true, false},
+		{"plugin.Open(\"x\"): x.so: file too short", true, false},
+		{"plugin.Open(\"x\"): x.so: cannot open shared object file: Too many open files", false, false},
+		{"plugin.Open(\"x\"): x.so: cannot open shared object file: Permission denied", false, false},
+		{"plugin.Open(\"x\"): x.so: failed to map segment from shared object", false, false},
+	}
+
+	for _, test := range tests {
+		err := classifyOpenError("x.so", errors.New(test.message))
+		if got := isInvalidPlugin(err); got != test.invalid {
+			t.Errorf("classifyOpenError(%q) invalid = %v, want %v", test.message, got, test.invalid)
+		}
+
+		var incompatible *IncompatibleError
+		if got := errors.As(err, &incompatible); got != test.incompatible {
+			t.Errorf("classifyOpenError(%q) incompatible = %v, want %v", test.message, got, test.incompatible)
+		} else if got && incompatible.Plugin != "x.so" {
+			t.Errorf("classifyOpenError(%q) plugin = %q, want x.so", test.message, incompatible.Plugin)
+		}
+	}
+}
+
+func TestLockfileSave(t *testing.T) {
+	pluginsDir := t.TempDir()
+
+	lock, err := ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Put(LockEntry{Name: "zeta", File: "daiv-zeta"})
+	lock.Put(LockEntry{Name: "alpha", File: "daiv-alpha.so"})
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != LockfileName {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("plugins directory contains %v, want only %s", names, LockfileName)
+	}
+
+	info, err := os.Stat(filepath.Join(pluginsDir, LockfileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("lockfile mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	saved, err := ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Plugins) != 2 || saved.Plugins[0].Name != "alpha" || saved.Plugins[1].Name != "zeta" {
+		t.Errorf("saved plugins = %+v, want alpha and zeta", saved.Plugins)
+	}
+
+	// A failed save leaves the previous lockfile in place
+	if runtime.GOOS != "windows" && os.Getuid() != 0 {
+		if err := os.Chmod(pluginsDir, 0555); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(pluginsDir, 0755)
+
+		saved.Remove("daiv-zeta")
+		if err := saved.Save(); err == nil {
+			t.Fatal("Save() into a read-only directory succeeded")
+		}
+
+		unchanged, err := ReadLockfile(pluginsDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unchanged.Plugins) != 2 {
+			t.Errorf("lockfile has %d plugins after a failed save, want 2", len(unchanged.Plugins))
+		}
+	}
+}
@@ -1,26 +1,52 @@
 package plugin
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	plug "github.com/iures/daivplug"
 )
 
-// Registry manages the registration and access of plugins
+// Registry manages the registration and access of plugins. It is safe for concurrent
+// use; accessors return snapshots in a stable order, see All.
+//
+// Plugins added with Add or LoadExternalPlugins are initialized when first used through
+// the Get*Plugins accessors or Use, so commands only initialize, and prompt for the
+// settings of, the plugins they need.
 type Registry struct {
-	mu       sync.RWMutex
-	Plugins  map[string]plug.Plugin
+	mu      sync.RWMutex
+	plugins map[string]plug.Plugin
+	// uninitialized holds the names of the registered plugins that weren't used yet
+	uninitialized map[string]bool
+	// registering holds the names of plugins being initialized by Register, which
+	// happens outside mu so lookups aren't blocked by a plugin prompting for its settings
+	registering map[string]bool
+	// externalLoaded is set once LoadExternalPlugins ran
+	externalLoaded bool
+
+	// initMu serializes initialization, which may prompt and updates the global config
+	initMu sync.Mutex
 }
 
 var (
-	globalRegistry = &Registry{
-		Plugins: make(map[string]plug.Plugin),
-	}
+	globalRegistry = NewRegistry()
 )
 
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		plugins:       make(map[string]plug.Plugin),
+		uninitialized: make(map[string]bool),
+		registering:   make(map[string]bool),
+	}
+}
+
 // GetRegistry returns the global plugin registry
 func GetRegistry() *Registry {
 	return globalRegistry
@@ -28,41 +54,137 @@ func GetRegistry() *Registry {
 
 func (r *Registry) GetStandupPlugins() []plug.StandupPlugin {
 	standupPlugins := []plug.StandupPlugin{}
-
-	for _, plugin := range r.Plugins {
-		// Check if it's a regular StandupPlugin
-		standupPlugin, ok := plugin.(plug.StandupPlugin)
-		if ok {
-			standupPlugins = append(standupPlugins, standupPlugin)
-		}
+	for _, plugin := range r.use(implements[plug.StandupPlugin](CapabilityStandup)) {
+		standupPlugins = append(standupPlugins, plugin.(plug.StandupPlugin))
 	}
 
 	return standupPlugins
 }
 
-func (r *Registry) Register(plugin plug.Plugin) error {
+// GetCommandPlugins returns the registered plugins that add subcommands to daiv
+func (r *Registry) GetCommandPlugins() []CommandPlugin {
+	commandPlugins := []CommandPlugin{}
+	for _, plugin := range r.use(implements[CommandPlugin](CapabilityCommands)) {
+		commandPlugins = append(commandPlugins, plugin.(CommandPlugin))
+	}
+
+	return commandPlugins
+}
+
+// GetActivityPlugins returns the registered plugins that report activity events
+func (r *Registry) GetActivityPlugins() []ActivityPlugin {
+	activityPlugins := []ActivityPlugin{}
+	for _, plugin := range r.use(implements[ActivityPlugin](CapabilityActivity)) {
+		activityPlugins = append(activityPlugins, plugin.(ActivityPlugin))
+	}
+
+	return activityPlugins
+}
+
+// GetTicketPlugins returns the registered plugins that look up tickets
+func (r *Registry) GetTicketPlugins() []TicketPlugin {
+	ticketPlugins := []TicketPlugin{}
+	for _, plugin := range r.use(implements[TicketPlugin](CapabilityTickets)) {
+		ticketPlugins = append(ticketPlugins, plugin.(TicketPlugin))
+	}
+
+	return ticketPlugins
+}
+
+// GetReviewPlugins returns the registered plugins that list items awaiting the user
+func (r *Registry) GetReviewPlugins() []ReviewPlugin {
+	reviewPlugins := []ReviewPlugin{}
+	for _, plugin := range r.use(implements[ReviewPlugin](CapabilityReviews)) {
+		reviewPlugins = append(reviewPlugins, plugin.(ReviewPlugin))
+	}
+
+	return reviewPlugins
+}
+
+// implements returns a filter for the plugins providing a capability through interface T.
+func implements[T any](capability string) func(plug.Plugin) bool {
+	return func(plugin plug.Plugin) bool {
+		_, ok := plugin.(T)
+		return ok && supports(plugin, capability)
+	}
+}
+
+// Add registers a plugin without initializing it, it is initialized when first used.
+func (r *Registry) Add(plugin plug.Plugin) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	name := plugin.Name()
-	if _, exists := r.Plugins[name]; exists {
-		return fmt.Errorf("reporter plugin %s is already registered", name)
+	if _, exists := r.plugins[name]; exists || r.registering[name] {
+		return fmt.Errorf("plugin %s is already registered", name)
 	}
 
-	// Then initialize it
-	if err := Initialize(plugin); err != nil {
-		return fmt.Errorf("failed to initialize plugin %s: %w", name, err)
+	r.plugins[name] = plugin
+	r.uninitialized[name] = true
+	return nil
+}
+
+// Register initializes a plugin right away and adds it to the registry. Disabled plugins
+// are skipped, and the plugin's dependencies must be registered and initialized first.
+func (r *Registry) Register(plugin plug.Plugin) error {
+	name := plugin.Name()
+
+	// Disabled plugins are not initialized, so they don't prompt for their settings
+	if !IsEnabled(name) {
+		if r.has(name) {
+			return fmt.Errorf("plugin %s is already registered", name)
+		}
+		return nil
 	}
 
-  r.Plugins[name] = plugin
+	return r.register(plugin)
+}
+
+// register reserves the plugin's name, initializes the plugin without holding the lock
+// and adds it once initialized.
+func (r *Registry) register(plugin plug.Plugin) error {
+	name := plugin.Name()
+
+	r.mu.Lock()
+	if _, exists := r.plugins[name]; exists || r.registering[name] {
+		r.mu.Unlock()
+		return fmt.Errorf("plugin %s is already registered", name)
+	}
+	for _, dependency := range Dependencies(plugin) {
+		if _, ok := r.plugins[dependency]; !ok || r.uninitialized[dependency] {
+			r.mu.Unlock()
+			return fmt.Errorf("plugin %s depends on %s, which is not registered", name, dependency)
+		}
+	}
+	r.registering[name] = true
+	r.mu.Unlock()
+
+	r.initMu.Lock()
+	err := Initialize(plugin)
+	r.initMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.registering, name)
+	if err != nil {
+		return fmt.Errorf("failed to initialize plugin %s: %w", name, err)
+	}
 
+	r.plugins[name] = plugin
 	return nil
 }
 
-// LoadExternalPlugins loads plugins from the plugins directory
+// LoadExternalPlugins loads the plugins from the plugins directory and adds them to the
+// registry, see Add. Only the first call loads them.
 func (r *Registry) LoadExternalPlugins() error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	loaded := r.externalLoaded
+	r.externalLoaded = true
+	r.mu.Unlock()
+	if loaded {
+		return nil
+	}
 
 	// Get plugins directory
 	homeDir, err := os.UserHomeDir()
@@ -71,7 +193,7 @@ func (r *Registry) LoadExternalPlugins() error {
 	}
 
 	pluginsDir := filepath.Join(homeDir, ".daiv", "plugins")
-	
+
 	// Create plugin manager
 	manager, err := NewPluginManager(pluginsDir)
 	if err != nil {
@@ -84,44 +206,234 @@ func (r *Registry) LoadExternalPlugins() error {
 		return fmt.Errorf("failed to load external plugins: %w", err)
 	}
 
-	// Register loaded plugins
 	for _, plugin := range loadedPlugins {
 		name := plugin.Name()
-		if _, exists := r.Plugins[name]; exists {
-			fmt.Printf("Warning: Plugin %s is already registered, skipping external version\n", name)
+
+		if !IsEnabled(name) {
+			release(plugin)
 			continue
 		}
 
-		// Initialize the plugin
-		if err := Initialize(plugin); err != nil {
-			fmt.Printf("Warning: Failed to initialize plugin %s: %v\n", name, err)
+		if err := r.Add(plugin); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Plugin %s is already registered, skipping external version\n", name)
+			release(plugin)
 			continue
 		}
 
-		r.Plugins[name] = plugin
-		fmt.Printf("Loaded external plugin: %s\n", name)
+		fmt.Fprintf(os.Stderr, "Loaded external plugin: %s\n", name)
+	}
+
+	// Plugins with missing dependencies or in a dependency cycle can never be used
+	r.mu.RLock()
+	registered := slices.Collect(maps.Values(r.plugins))
+	r.mu.RUnlock()
+
+	_, skipped := resolveOrder(registered, nil)
+	for _, name := range slices.Sorted(maps.Keys(skipped)) {
+		fmt.Fprintf(os.Stderr, "Warning: Skipping plugin %s: %v\n", name, skipped[name])
+		if plugin, ok := r.Get(name); ok {
+			r.remove(plugin)
+		}
 	}
 
 	return nil
 }
 
-// Retrieve plugin by name
+// use returns the enabled plugins that match, in order, initializing the ones that
+// weren't used yet after their dependencies. Plugins that fail to initialize are
+// removed from the registry with a warning.
+func (r *Registry) use(matches func(plug.Plugin) bool) []plug.Plugin {
+	var matching []plug.Plugin
+	for _, plugin := range r.All() {
+		if matches(plugin) && IsEnabled(plugin.Name()) {
+			matching = append(matching, plugin)
+		}
+	}
+
+	if err := r.initialize(matching); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var used []plug.Plugin
+	for _, plugin := range matching {
+		if r.isInitialized(plugin.Name()) {
+			used = append(used, plugin)
+		}
+	}
+	return used
+}
+
+// Use returns a registered plugin by name, initializing it and its dependencies if it
+// wasn't used yet.
+func (r *Registry) Use(name string) (plug.Plugin, error) {
+	plugin, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s is not registered", name)
+	}
+
+	if err := r.initialize([]plug.Plugin{plugin}); err != nil {
+		return nil, err
+	}
+	if !r.isInitialized(name) {
+		return nil, fmt.Errorf("plugin %s failed to initialize", name)
+	}
+
+	return plugin, nil
+}
+
+// initialize initializes the given plugins and the ones they depend on, dependencies first.
+func (r *Registry) initialize(plugins []plug.Plugin) error {
+	r.initMu.Lock()
+	defer r.initMu.Unlock()
+
+	// Collect the plugins to initialize along with their dependencies
+	pending := make(map[string]plug.Plugin)
+	queue := slices.Clone(plugins)
+	for len(queue) > 0 {
+		plugin := queue[0]
+		queue = queue[1:]
+
+		name := plugin.Name()
+		if _, seen := pending[name]; seen || r.isInitialized(name) {
+			continue
+		}
+		pending[name] = plugin
+
+		for _, dependency := range Dependencies(plugin) {
+			if dep, ok := r.Get(dependency); ok {
+				queue = append(queue, dep)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	initialized := make(map[string]bool)
+	for _, name := range r.Names() {
+		if r.isInitialized(name) {
+			initialized[name] = true
+		}
+	}
+
+	ordered, skipped := resolveOrder(slices.Collect(maps.Values(pending)), initialized)
+
+	var errs []error
+	for name, reason := range skipped {
+		errs = append(errs, fmt.Errorf("skipping plugin %s: %w", name, reason))
+		r.remove(pending[name])
+	}
+
+	for _, plugin := range ordered {
+		name := plugin.Name()
+
+		if missing := slices.IndexFunc(Dependencies(plugin), func(d string) bool { return !r.isInitialized(d) }); missing >= 0 {
+			errs = append(errs, fmt.Errorf("skipping plugin %s: dependency %s failed to initialize", name, Dependencies(plugin)[missing]))
+			r.remove(plugin)
+			continue
+		}
+
+		if err := Initialize(plugin); err != nil {
+			errs = append(errs, fmt.Errorf("failed to initialize plugin %s: %w", name, err))
+			r.remove(plugin)
+			continue
+		}
+
+		r.mu.Lock()
+		delete(r.uninitialized, name)
+		r.mu.Unlock()
+	}
+
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// remove drops a plugin that failed to initialize from the registry.
+func (r *Registry) remove(plugin plug.Plugin) {
+	r.mu.Lock()
+	delete(r.plugins, plugin.Name())
+	delete(r.uninitialized, plugin.Name())
+	r.mu.Unlock()
+
+	release(plugin)
+}
+
+func (r *Registry) isInitialized(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.plugins[name]
+	return ok && !r.uninitialized[name]
+}
+
+// release stops the process of an out-of-process plugin that will not be registered
+func release(plugin plug.Plugin) {
+	if p, ok := plugin.(*processPlugin); ok {
+		p.Shutdown()
+	}
+}
+
+// Get retrieves a registered plugin by name, which may not be initialized yet, see Use
 func (r *Registry) Get(name string) (plug.Plugin, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-  plugin, ok := r.Plugins[name]
+
+	plugin, ok := r.plugins[name]
 	return plugin, ok
 }
 
-// ShutdownAll gracefully shuts down all plugins
+func (r *Registry) has(name string) bool {
+	_, ok := r.Get(name)
+	return ok
+}
+
+// Names returns the names of the registered plugins in order, see All
+func (r *Registry) Names() []string {
+	var names []string
+	for _, plugin := range r.All() {
+		names = append(names, plugin.Name())
+	}
+	return names
+}
+
+// All returns the registered plugins after their dependencies, then by descending
+// priority and name. Plugins that weren't used yet are included but not initialized,
+// so only call into them through the Get*Plugins accessors or Use.
+func (r *Registry) All() []plug.Plugin {
+	r.mu.RLock()
+	plugins := make([]plug.Plugin, 0, len(r.plugins))
+	for _, plugin := range r.plugins {
+		plugins = append(plugins, plugin)
+	}
+	r.mu.RUnlock()
+
+	ordered, _ := resolveOrder(plugins, nil)
+	return ordered
+}
+
+// ShutdownAll gracefully shuts down all plugins and removes them from the registry.
+// Dependents are shut down before their dependencies, plugins that weren't used are
+// only released.
 func (r *Registry) ShutdownAll() error {
+	plugins := r.All()
+
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	uninitialized := r.uninitialized
+	for _, plugin := range plugins {
+		delete(r.plugins, plugin.Name())
+	}
+	r.uninitialized = make(map[string]bool)
+	r.mu.Unlock()
 
 	var errs []error
-	for name, plugin := range r.Plugins {
-		if err := plugin.Shutdown(); err != nil {
-			errs = append(errs, fmt.Errorf("failed to shutdown plugin %s: %w", name, err))
+	for i := len(plugins) - 1; i >= 0; i-- {
+		if uninitialized[plugins[i].Name()] {
+			release(plugins[i])
+			continue
+		}
+
+		if err := plugins[i].Shutdown(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown plugin %s: %w", plugins[i].Name(), err))
 		}
 	}
 
@@ -0,0 +1,326 @@
+package plugin
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	plug "github.com/iures/daivplug"
+)
+
+type fakePlugin struct {
+	name         string
+	dependencies []string
+	priority     int
+
+	// started, if set, is closed when Initialize starts, and block holds it until closed
+	started     chan struct{}
+	block       chan struct{}
+	initialized atomic.Int32
+	shutdown    func(name string)
+}
+
+func (p *fakePlugin) Name() string                   { return p.name }
+func (p *fakePlugin) Manifest() *plug.PluginManifest { return &plug.PluginManifest{} }
+func (p *fakePlugin) Dependencies() []string         { return p.dependencies }
+func (p *fakePlugin) Priority() int                  { return p.priority }
+
+func (p *fakePlugin) Initialize(settings map[string]any) error {
+	if p.started != nil {
+		close(p.started)
+	}
+	if p.block != nil {
+		<-p.block
+	}
+	p.initialized.Add(1)
+	return nil
+}
+
+func (p *fakePlugin) Shutdown() error {
+	if p.shutdown != nil {
+		p.shutdown(p.name)
+	}
+	return nil
+}
+
+func (p *fakePlugin) GetStandupContext(timeRange plug.TimeRange) (plug.StandupContext, error) {
+	return plug.StandupContext{PluginName: p.name}, nil
+}
+
+func TestRegistryConcurrentRegistrationAndLookup(t *testing.T) {
+	registry := NewRegistry()
+
+	const count = 50
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				registry.Get("plugin-00")
+				registry.All()
+				registry.Names()
+				registry.GetStandupPlugins()
+			}
+		}()
+	}
+
+	var registering sync.WaitGroup
+	for i := 0; i < count; i++ {
+		registering.Add(1)
+		go func(i int) {
+			defer registering.Done()
+			if err := registry.Register(&fakePlugin{name: fmt.Sprintf("plugin-%02d", i)}); err != nil {
+				t.Errorf("Register: %v", err)
+			}
+		}(i)
+	}
+
+	registering.Wait()
+	close(stop)
+	wg.Wait()
+
+	names := registry.Names()
+	if len(names) != count {
+		t.Fatalf("registered %d plugins, want %d", len(names), count)
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Names() = %v, want sorted", names)
+	}
+	if got := len(registry.GetStandupPlugins()); got != count {
+		t.Errorf("GetStandupPlugins() returned %d plugins, want %d", got, count)
+	}
+}
+
+func TestRegistryConcurrentDuplicateRegistration(t *testing.T) {
+	registry := NewRegistry()
+
+	const count = 20
+	plugins := make([]*fakePlugin, count)
+	errs := make([]error, count)
+
+	var wg sync.WaitGroup
+	for i := range plugins {
+		plugins[i] = &fakePlugin{name: "duplicate"}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = registry.Register(plugins[i])
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		if err == nil {
+			succeeded++
+		}
+		if got := plugins[i].initialized.Load(); (err == nil) != (got == 1) {
+			t.Errorf("plugin %d: Register error %v, initialized %d times", i, err, got)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d registrations succeeded, want 1", succeeded)
+	}
+}
+
+func TestRegistryLookupDuringInitialize(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&fakePlugin{name: "ready"}); err != nil {
+		t.Fatal(err)
+	}
+
+	slow := &fakePlugin{name: "slow", started: make(chan struct{}), block: make(chan struct{})}
+	registered := make(chan error, 1)
+	go func() {
+		registered <- registry.Register(slow)
+	}()
+	<-slow.started
+
+	lookedUp := make(chan bool, 1)
+	go func() {
+		_, ok := registry.Get("ready")
+		registry.All()
+		lookedUp <- ok
+	}()
+
+	select {
+	case ok := <-lookedUp:
+		if !ok {
+			t.Error("Get(ready) did not find the registered plugin")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lookup blocked while another plugin was initializing")
+	}
+
+	if _, ok := registry.Get("slow"); ok {
+		t.Error("plugin is visible before it finished initializing")
+	}
+	if err := registry.Register(&fakePlugin{name: "slow"}); err == nil {
+		t.Error("registering a plugin that is being initialized succeeded")
+	}
+
+	close(slow.block)
+	if err := <-registered; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := registry.Get("slow"); !ok {
+		t.Error("plugin is missing after it finished initializing")
+	}
+}
+
+func TestRegistryOrder(t *testing.T) {
+	registry := NewRegistry()
+
+	var mu sync.Mutex
+	var shutdownOrder []string
+	shutdown := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		shutdownOrder = append(shutdownOrder, name)
+	}
+
+	for _, plugin := range []*fakePlugin{
+		{name: "zeta"},
+		{name: "alpha"},
+		{name: "urgent", priority: 10},
+		{name: "beta", dependencies: []string{"zeta"}},
+	} {
+		plugin.shutdown = shutdown
+		if err := registry.Register(plugin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := registry.Register(&fakePlugin{name: "orphan", dependencies: []string{"missing"}}); err == nil {
+		t.Error("registering a plugin with a missing dependency succeeded")
+	}
+
+	want := []string{"urgent", "alpha", "zeta", "beta"}
+	for i := 0; i < 10; i++ {
+		if got := registry.Names(); !slices.Equal(got, want) {
+			t.Fatalf("Names() = %v, want %v", got, want)
+		}
+	}
+
+	if err := registry.ShutdownAll(); err != nil {
+		t.Fatal(err)
+	}
+	slices.Reverse(want)
+	if !slices.Equal(shutdownOrder, want) {
+		t.Errorf("shut down in order %v, want %v", shutdownOrder, want)
+	}
+	if names := registry.Names(); len(names) != 0 {
+		t.Errorf("Names() = %v after ShutdownAll, want none", names)
+	}
+}
+
+// quietPlugin provides no capability besides the base Plugin interface.
+type quietPlugin struct {
+	name        string
+	initialized atomic.Int32
+}
+
+func (p *quietPlugin) Name() string                             { return p.name }
+func (p *quietPlugin) Manifest() *plug.PluginManifest           { return &plug.PluginManifest{} }
+func (p *quietPlugin) Initialize(settings map[string]any) error { p.initialized.Add(1); return nil }
+func (p *quietPlugin) Shutdown() error                          { return nil }
+func (p *quietPlugin) Dependencies() []string                   { return nil }
+
+func TestRegistryInitializesPluginsWhenUsed(t *testing.T) {
+	registry := NewRegistry()
+
+	base := &quietPlugin{name: "base"}
+	unused := &quietPlugin{name: "unused"}
+	standup := &fakePlugin{name: "standup", dependencies: []string{"base"}}
+
+	for _, plugin := range []plug.Plugin{base, unused, standup} {
+		if err := registry.Add(plugin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := registry.Names(); !slices.Equal(got, []string{"base", "standup", "unused"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	if base.initialized.Load() != 0 || standup.initialized.Load() != 0 {
+		t.Fatal("Add initialized a plugin")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := registry.GetStandupPlugins(); len(got) != 1 || got[0].Name() != "standup" {
+				t.Errorf("GetStandupPlugins() = %v", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := standup.initialized.Load(); got != 1 {
+		t.Errorf("standup initialized %d times, want 1", got)
+	}
+	if got := base.initialized.Load(); got != 1 {
+		t.Errorf("dependency initialized %d times, want 1", got)
+	}
+	if got := unused.initialized.Load(); got != 0 {
+		t.Errorf("unused plugin initialized %d times, want 0", got)
+	}
+
+	if _, err := registry.Use("unused"); err != nil {
+		t.Fatal(err)
+	}
+	if got := unused.initialized.Load(); got != 1 {
+		t.Errorf("Use initialized the plugin %d times, want 1", got)
+	}
+	if _, err := registry.Use("missing"); err == nil {
+		t.Error("Use of an unregistered plugin succeeded")
+	}
+}
+
+// errorHookPlugin records the commands it was told failed.
+type errorHookPlugin struct {
+	quietPlugin
+	failed []string
+}
+
+func (p *errorHookPlugin) OnError(command string, err error) {
+	p.failed = append(p.failed, command)
+}
+
+func TestRunOnErrorSkipsUninitializedPlugins(t *testing.T) {
+	registry := NewRegistry()
+
+	used := &errorHookPlugin{quietPlugin: quietPlugin{name: "used"}}
+	unused := &errorHookPlugin{quietPlugin: quietPlugin{name: "unused"}}
+	for _, plugin := range []plug.Plugin{used, unused} {
+		if err := registry.Add(plugin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := registry.Use("used"); err != nil {
+		t.Fatal(err)
+	}
+
+	registry.RunOnError("standup", fmt.Errorf("failed"))
+
+	if !slices.Equal(used.failed, []string{"standup"}) {
+		t.Errorf("initialized plugin was told %v, want [standup]", used.failed)
+	}
+	if len(unused.failed) != 0 || unused.initialized.Load() != 0 {
+		t.Errorf("uninitialized plugin was told %v and initialized %d times, want neither", unused.failed, unused.initialized.Load())
+	}
+}
@@ -0,0 +1,275 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// previousSuffix is appended to the file of the plugin version replaced by an update.
+const previousSuffix = ".prev"
+
+// ErrUpToDate is returned by Update when the plugin is already at the latest version.
+var ErrUpToDate = errors.New("plugin is up to date")
+
+// Update re-resolves the recorded source of an installed plugin and reinstalls it when a
+// newer version is available. The replaced binary is kept for Rollback.
+func (pm *PluginManager) Update(name string) error {
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := lock.Find(name)
+	if !ok {
+		return fmt.Errorf("plugin '%s' is not recorded in %s, reinstall it to enable updates", name, LockfileName)
+	}
+	current := *entry
+	current.Previous = nil
+
+	version := current.RequestedVersion
+	switch current.Source.Type {
+	case SourceGitHub, SourceGit:
+		resolved, commit, err := resolveGitUpdate(current)
+		if err != nil {
+			return err
+		}
+		if commit == current.ResolvedCommit {
+			return ErrUpToDate
+		}
+		if resolved != current.RequestedVersion {
+			fmt.Printf("Updating %s from %s to %s\n", current.Name, current.RequestedVersion, resolved)
+		} else {
+			fmt.Printf("Updating %s to commit %s\n", current.Name, shortCommit(commit))
+		}
+		version = resolved
+
+	case SourceLocal:
+		checksum, err := fileChecksum(current.Source.Location)
+		if err != nil {
+			return fmt.Errorf("failed to read plugin source %s: %w", current.Source.Location, err)
+		}
+		if checksum == current.Checksum {
+			return ErrUpToDate
+		}
+
+	case SourceDir:
+		sourceChecksum, err := copySourceDir(current.Source.Location, "")
+		if err == nil && sourceChecksum == current.SourceChecksum {
+			return ErrUpToDate
+		}
+
+	case SourceURL:
+		// There is no version information to compare, so download it again and
+		// compare checksums afterwards
+
+	default:
+		return fmt.Errorf("plugin '%s' has unknown source %q", current.Name, current.Source.Type)
+	}
+
+	pluginPath := filepath.Join(pm.pluginsDir, current.File)
+	previousPath := pluginPath + previousSuffix
+
+	// Moving the binary aside, instead of copying it, also works for running
+	// out-of-process plugins
+	if err := os.Rename(pluginPath, previousPath); err != nil {
+		return fmt.Errorf("failed to keep previous version: %w", err)
+	}
+
+	if err := pm.installFromSource(current.Source, version, current.ExpectedChecksum); err != nil {
+		os.Rename(previousPath, pluginPath)
+		if current.ExpectedChecksum != "" {
+			return fmt.Errorf("%w; the plugin was installed with --sha256, reinstall it with the new checksum to update it", err)
+		}
+		return err
+	}
+
+	lock, err = ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	updated, ok := lock.FindFile(current.File)
+	if !ok {
+		return fmt.Errorf("updated plugin %s was not recorded in %s", current.File, LockfileName)
+	}
+
+	if updated.Checksum == current.Checksum {
+		os.Rename(previousPath, pluginPath)
+		lock.Put(current)
+		if err := lock.Save(); err != nil {
+			return err
+		}
+		return ErrUpToDate
+	}
+
+	updated.Previous = &current
+	return lock.Save()
+}
+
+// Rollback restores the version of a plugin that was replaced by its last update.
+func (pm *PluginManager) Rollback(name string) error {
+	lock, err := ReadLockfile(pm.pluginsDir)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := lock.Find(name)
+	if !ok {
+		return fmt.Errorf("plugin '%s' is not recorded in %s", name, LockfileName)
+	}
+
+	pluginPath := filepath.Join(pm.pluginsDir, entry.File)
+	previousPath := pluginPath + previousSuffix
+
+	if entry.Previous == nil {
+		return fmt.Errorf("plugin '%s' has no previous version to roll back to", name)
+	}
+	if _, err := os.Stat(previousPath); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("previous version of plugin '%s' is missing: %s", name, previousPath)
+	}
+
+	if err := os.Rename(previousPath, pluginPath); err != nil {
+		return fmt.Errorf("failed to restore previous version: %w", err)
+	}
+
+	lock.Put(*entry.Previous)
+	return lock.Save()
+}
+
+// resolveGitUpdate returns the version to install and the commit it resolves to.
+// Plugins pinned to a semver tag move to the newest release tag, plugins installed
+// from a branch, or without a version, follow that branch. Commits stay pinned.
+func resolveGitUpdate(entry LockEntry) (string, string, error) {
+	repoURL := entry.Source.Location
+	if entry.Source.Type == SourceGitHub {
+		repoURL = fmt.Sprintf("https://github.com/%s.git", entry.Source.Location)
+	}
+
+	refs, err := lsRemote(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	version := entry.RequestedVersion
+
+	if version == "" {
+		commit, ok := refs["HEAD"]
+		if !ok {
+			return "", "", fmt.Errorf("failed to resolve default branch of %s", entry.Source.Location)
+		}
+		return "", commit, nil
+	}
+
+	if _, ok := parseSemver(version); ok {
+		latest := version
+		for ref := range refs {
+			tag, ok := strings.CutPrefix(ref, "refs/tags/")
+			if ok && compareSemver(tag, latest) > 0 {
+				latest = tag
+			}
+		}
+		if commit, ok := refs["refs/tags/"+latest]; ok {
+			return latest, commit, nil
+		}
+	}
+
+	if commit, ok := refs["refs/heads/"+version]; ok {
+		return version, commit, nil
+	}
+
+	// Commits and tags that no longer exist can't be updated
+	return version, entry.ResolvedCommit, nil
+}
+
+// lsRemote lists the refs of a remote repository, resolving annotated tags to their commit.
+func lsRemote(repoURL string) (map[string]string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", "ls-remote", repoURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list refs of %s: %w: %s", repoURL, err, strings.TrimSpace(stderr.String()))
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		commit, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+
+		if tag, peeled := strings.CutSuffix(ref, "^{}"); peeled {
+			refs[tag] = commit
+		} else if _, seen := refs[ref]; !seen {
+			refs[ref] = commit
+		}
+	}
+
+	return refs, nil
+}
+
+// parseSemver parses release versions like v1.2.3. Pre-releases, build metadata and
+// versions without the v prefix are not considered.
+func parseSemver(version string) ([3]int, bool) {
+	var parsed [3]int
+
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if !strings.HasPrefix(version, "v") || len(parts) != 3 {
+		return parsed, false
+	}
+
+	for i, part := range parts {
+		// Atoi accepts signs, which don't belong in a version
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return parsed, false
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+
+	return parsed, true
+}
+
+// compareSemver compares two versions, treating anything that isn't a release version as older.
+func compareSemver(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,315 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		version string
+		want    [3]int
+		ok      bool
+	}{
+		{"v1.2.3", [3]int{1, 2, 3}, true},
+		{"v0.0.0", [3]int{0, 0, 0}, true},
+		{"v10.20.30", [3]int{10, 20, 30}, true},
+		{"1.2.3", [3]int{}, false},
+		{"v1.2", [3]int{}, false},
+		{"v1.2.3.4", [3]int{}, false},
+		{"v1.2.3-rc.1", [3]int{}, false},
+		{"v1.2.3+build.5", [3]int{}, false},
+		{"v1.2.+3", [3]int{}, false},
+		{"v1.-2.3", [3]int{}, false},
+		{"v1..3", [3]int{}, false},
+		{"vx.y.z", [3]int{}, false},
+		{"main", [3]int{}, false},
+		{"", [3]int{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			got, ok := parseSemver(test.version)
+			if ok != test.ok || (ok && got != test.want) {
+				t.Errorf("parseSemver(%q) = %v, %v, want %v, %v", test.version, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.4", "v1.2.3", 1},
+		{"v1.3.0", "v1.2.9", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.2.3", "v1.2.4", -1},
+		// Pre-releases and malformed tags are older than any release
+		{"v1.3.0-rc.1", "v1.2.0", -1},
+		{"v1.2.0", "v1.3.0-rc.1", 1},
+		{"1.5.0", "v1.0.0", -1},
+		{"latest", "v0.0.1", -1},
+		{"v1.3.0-rc.1", "v1.3.0-rc.2", 0},
+		{"latest", "main", 0},
+	}
+
+	for _, test := range tests {
+		if got := compareSemver(test.a, test.b); got != test.want {
+			t.Errorf("compareSemver(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestResolveGitUpdate(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	git("init", "-q", "-b", "main")
+	commits := map[string]string{}
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0-rc.1"} {
+		git("commit", "-q", "--allow-empty", "-m", tag)
+		git("tag", "-a", tag, "-m", tag)
+		commits[tag] = git("rev-parse", "HEAD")
+	}
+	git("tag", "vnext")
+	git("checkout", "-q", "-b", "develop")
+	git("commit", "-q", "--allow-empty", "-m", "develop")
+	commits["develop"] = git("rev-parse", "HEAD")
+	git("checkout", "-q", "main")
+
+	tests := []struct {
+		name        string
+		version     string
+		resolved    string
+		wantVersion string
+		wantCommit  string
+	}{
+		{"pinned tag moves to the latest release", "v1.0.0", commits["v1.0.0"], "v1.2.0", commits["v1.2.0"]},
+		{"latest release stays", "v1.2.0", commits["v1.2.0"], "v1.2.0", commits["v1.2.0"]},
+		{"branch follows its head", "develop", "old", "develop", commits["develop"]},
+		{"no version follows the default branch", "", "old", "", commits["v1.3.0-rc.1"]},
+		{"commit stays pinned", commits["v1.1.0"], commits["v1.1.0"], commits["v1.1.0"], commits["v1.1.0"]},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := LockEntry{
+				Source:           LockSource{Type: SourceGit, Location: repo},
+				RequestedVersion: test.version,
+				ResolvedCommit:   test.resolved,
+			}
+
+			version, commit, err := resolveGitUpdate(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != test.wantVersion || commit != test.wantCommit {
+				t.Errorf("resolveGitUpdate() = %s, %s, want %s, %s", version, commit, test.wantVersion, test.wantCommit)
+			}
+		})
+	}
+}
+
+func TestUpdateRestoresPreviousVersionWhenBuildFails(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	pluginsDir := t.TempDir()
+	sourceDir := filepath.Join(t.TempDir(), "daiv-broken")
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(sourceDir, "go.mod"), "module example.com/broken\n\ngo 1.23\n")
+	writeFile(t, filepath.Join(sourceDir, "main.go"), "package main\n\nfunc main() {\n")
+
+	pluginPath := filepath.Join(pluginsDir, "daiv-broken.so")
+	writeFile(t, pluginPath, "previous build")
+	checksum, err := fileChecksum(pluginPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installed := LockEntry{
+		Name:           "daiv-broken",
+		File:           "daiv-broken.so",
+		Source:         LockSource{Type: SourceDir, Location: sourceDir},
+		Checksum:       checksum,
+		SourceChecksum: "sha256:outdated",
+	}
+	lock.Put(installed)
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := NewPluginManager(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = pm.Update("daiv-broken")
+	if err == nil || errors.Is(err, ErrUpToDate) {
+		t.Fatalf("Update() = %v, want a build error", err)
+	}
+
+	if data, err := os.ReadFile(pluginPath); err != nil || string(data) != "previous build" {
+		t.Errorf("plugin file = %q, %v after the failed update, want the previous build", data, err)
+	}
+	if _, err := os.Stat(pluginPath + previousSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s is left behind after the failed update: %v", previousSuffix, err)
+	}
+
+	lock, err = ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Find("daiv-broken")
+	if !ok || entry.Checksum != checksum || entry.Previous != nil {
+		t.Errorf("lockfile entry = %+v after the failed update, want it unchanged", entry)
+	}
+}
+
+func TestUpdateVerifiesRecordedChecksum(t *testing.T) {
+	content := "first build"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/daiv-pinned.dll" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	// .dll files are installed without opening them, so any content will do
+	pluginsDir := t.TempDir()
+	pm, err := NewPluginManager(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	expected := hex.EncodeToString(sum[:])
+	url := server.URL + "/daiv-pinned.dll"
+	if err := pm.InstallFromURL(url, expected); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Find("daiv-pinned")
+	if !ok || entry.ExpectedChecksum != expected {
+		t.Fatalf("lockfile entry = %+v, want the expected checksum recorded", entry)
+	}
+
+	// The same download is verified and left as is
+	if err := pm.Update("daiv-pinned"); !errors.Is(err, ErrUpToDate) {
+		t.Errorf("Update() of an unchanged download = %v, want ErrUpToDate", err)
+	}
+
+	// A changed download no longer matches the checksum the user pinned
+	content = "tampered build"
+	err = pm.Update("daiv-pinned")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Update() of a changed download = %v, want a checksum mismatch", err)
+	}
+
+	pluginPath := filepath.Join(pluginsDir, "daiv-pinned.dll")
+	if data, err := os.ReadFile(pluginPath); err != nil || string(data) != "first build" {
+		t.Errorf("plugin file = %q, %v after the rejected update, want the first build", data, err)
+	}
+	if _, err := os.Stat(pluginPath + previousSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s is left behind after the rejected update: %v", previousSuffix, err)
+	}
+
+	lock, err = ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok := lock.Find("daiv-pinned"); !ok || entry.ExpectedChecksum != expected || entry.Previous != nil {
+		t.Errorf("lockfile entry = %+v after the rejected update, want it unchanged", entry)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	pluginsDir := t.TempDir()
+	pluginPath := filepath.Join(pluginsDir, "daiv-echo")
+	writeFile(t, pluginPath, "new version")
+	writeFile(t, pluginPath+previousSuffix, "old version")
+
+	previous := LockEntry{Name: "echo", File: "daiv-echo", Checksum: "sha256:old", RequestedVersion: "v1.0.0"}
+	current := previous
+	current.Checksum = "sha256:new"
+	current.RequestedVersion = "v1.1.0"
+	current.Previous = &previous
+
+	lock, err := ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.Put(current)
+	if err := lock.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := NewPluginManager(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.Rollback("echo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(pluginPath); err != nil || string(data) != "old version" {
+		t.Errorf("plugin file = %q, %v after rollback, want the old version", data, err)
+	}
+
+	lock, err = ReadLockfile(pluginsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Find("echo")
+	if !ok || entry.RequestedVersion != "v1.0.0" || entry.Previous != nil {
+		t.Errorf("lockfile entry = %+v after rollback, want the previous entry", entry)
+	}
+
+	if err := pm.Rollback("echo"); err == nil {
+		t.Error("a second rollback succeeded without a previous version")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
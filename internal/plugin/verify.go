@@ -0,0 +1,238 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Files published next to a plugin to verify its integrity.
+const (
+	checksumSuffix  = ".sha256"
+	signatureSuffix = ".minisig"
+)
+
+// siblingFetcher returns the contents of the file published next to a plugin with the
+// given suffix, or nil when there is none.
+type siblingFetcher func(suffix string) ([]byte, error)
+
+// urlSibling fetches sibling files of a plugin download URL.
+func urlSibling(url string) siblingFetcher {
+	return func(suffix string) ([]byte, error) {
+		resp, err := http.Get(url + suffix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", url+suffix, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to download %s: HTTP status %d", url+suffix, resp.StatusCode)
+		}
+
+		return io.ReadAll(resp.Body)
+	}
+}
+
+// fileSibling reads sibling files of a local plugin file.
+func fileSibling(path string) siblingFetcher {
+	return func(suffix string) ([]byte, error) {
+		data, err := os.ReadFile(path + suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return data, err
+	}
+}
+
+// verifyPlugin checks a plugin file against the expected sha256 checksum, if any, the
+// published .sha256 file and the published minisign signature. Signatures are verified
+// against the keys in plugins.trustedKeys; with plugins.requireSignature set, unsigned
+// plugins are rejected. It returns the id of the key that signed the plugin, if any.
+func verifyPlugin(path string, expectedChecksum string, sibling siblingFetcher) (string, error) {
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	actual := strings.TrimPrefix(checksum, "sha256:")
+
+	if expectedChecksum != "" {
+		expected := strings.ToLower(strings.TrimPrefix(expectedChecksum, "sha256:"))
+		if expected != actual {
+			return "", fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", expected, actual)
+		}
+	}
+
+	published, err := sibling(checksumSuffix)
+	if err != nil {
+		return "", err
+	}
+	if published != nil {
+		// The format of sha256sum: "<hex>  <filename>"
+		fields := strings.Fields(string(published))
+		if len(fields) == 0 {
+			return "", fmt.Errorf("published %s file is empty", checksumSuffix)
+		}
+		if expected := strings.ToLower(fields[0]); expected != actual {
+			return "", fmt.Errorf("checksum mismatch: published sha256 is %s, got %s", expected, actual)
+		}
+	}
+
+	signature, err := sibling(signatureSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	if signature == nil {
+		if viper.GetBool("plugins.requireSignature") {
+			return "", fmt.Errorf("plugin is not signed and plugins.requireSignature is enabled")
+		}
+		return "", nil
+	}
+
+	keys, err := trustedKeys()
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		if viper.GetBool("plugins.requireSignature") {
+			return "", fmt.Errorf("plugins.requireSignature is enabled but no plugins.trustedKeys are configured")
+		}
+		fmt.Printf("Warning: plugin is signed but no plugins.trustedKeys are configured, skipping signature verification\n")
+		return "", nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	keyID, err := verifyMinisign(content, signature, keys)
+	if err != nil {
+		return "", fmt.Errorf("signature verification failed: %w", err)
+	}
+
+	return keyID, nil
+}
+
+// minisignKey is a minisign ed25519 public key.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// trustedKeys parses the public keys configured in plugins.trustedKeys.
+func trustedKeys() ([]minisignKey, error) {
+	var keys []minisignKey
+	for _, value := range viper.GetStringSlice("plugins.trustedKeys") {
+		key, err := parseMinisignKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in plugins.trustedKeys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parseMinisignKey parses a public key, either the base64 line or the whole minisign.pub file.
+func parseMinisignKey(value string) (minisignKey, error) {
+	var key minisignKey
+
+	encoded := ""
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "untrusted comment:") {
+			encoded = line
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return key, err
+	}
+	if len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+		return key, fmt.Errorf("not a minisign ed25519 public key")
+	}
+
+	copy(key.id[:], data[2:10])
+	key.key = ed25519.PublicKey(data[10:])
+	return key, nil
+}
+
+// verifyMinisign verifies a minisign signature of content, including its trusted comment,
+// and returns the hex id of the key that made it.
+func verifyMinisign(content []byte, signatureFile []byte, keys []minisignKey) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(string(signatureFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return "", fmt.Errorf("malformed signature file")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(signature) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature")
+	}
+
+	trustedComment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return "", fmt.Errorf("malformed trusted comment")
+	}
+
+	globalSignature, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed global signature")
+	}
+
+	// "Ed" signs the content itself, "ED" (the default since minisign 0.10) its BLAKE2b-512 hash
+	message := content
+	switch string(signature[:2]) {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(content)
+		message = hash[:]
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", signature[:2])
+	}
+
+	keyID := signature[2:10]
+	for _, key := range keys {
+		if !bytes.Equal(key.id[:], keyID) {
+			continue
+		}
+
+		if !ed25519.Verify(key.key, message, signature[10:]) {
+			return "", fmt.Errorf("invalid signature")
+		}
+
+		signedComment := append(bytes.Clone(signature[10:]), trustedComment...)
+		if !ed25519.Verify(key.key, signedComment, globalSignature) {
+			return "", fmt.Errorf("invalid trusted comment signature")
+		}
+
+		return formatKeyID(key.id), nil
+	}
+
+	return "", fmt.Errorf("signed by untrusted key %s", formatKeyID([8]byte(keyID)))
+}
+
+// formatKeyID formats a key id the way minisign displays it.
+func formatKeyID(id [8]byte) string {
+	// minisign stores the id little-endian
+	reversed := make([]byte, len(id))
+	for i := range id {
+		reversed[i] = id[len(id)-1-i]
+	}
+	return strings.ToUpper(hex.EncodeToString(reversed))
+}
@@ -0,0 +1,227 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// The fixtures were made with minisign: the first key and signatures come from the tests of
+// github.com/jedisct1/go-minisign, the second from aead.dev/minisign's testdata.
+const (
+	testPublicKey   = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	testPublicKeyID = "E7620F1842B4E81F"
+	testContent     = "test"
+
+	// Ed: signs the content itself
+	testLegacySignature = "untrusted comment: signature from minisign secret key\n" +
+		"RWQf6LRCGA9i59SLOFxz6NxvASXDJeRtuZykwQepbDEGt87ig1BNpWaVWuNrm73YiIiJbq71Wi+dP9eKL8OC351vwIasSSbXxwA=\n" +
+		"trusted comment: timestamp:1635442742\tfile:test\n" +
+		"0YteLgV960ia80vnA/fHbvkyjl/IoP/HNOCaZfrF0CdhAlp7ok+Tpkya+VpWPX5C/Is3q8a/kEDSY7fBmmgJCg==\n"
+
+	// ED: signs the BLAKE2b-512 hash of the content
+	testPrehashedSignature = "untrusted comment: signature from minisign secret key\n" +
+		"RUQf6LRCGA9i559r3g7V1qNyJDApGip8MfqcadIgT9CuhV3EMhHoN1mGTkUidF/z7SrlQgXdy8ofjb7bNJJylDOocrCo8KLzZwo=\n" +
+		"trusted comment: timestamp:1635443258\tfile:test\thashed\n" +
+		"/cj37GK60vryibFn+ftOgbCvW9NKhKYgjVpFFQUcWPAnjO23wrvVDTt7cloNC06maoBli9q6qwZDXXoaxweICQ==\n"
+
+	otherPublicKeyFile = "untrusted comment: minisign public key C373193807678450\n" +
+		"RWRQhGcHOBlzw4CoKyugkk4ioDfoxlXxC9LBx+VNhJ3w9w+cAxgvPsuo\n"
+	otherPublicKeyID = "C373193807678450"
+	otherContent     = "Hello World!\n"
+	otherSignature   = "untrusted comment: signature from minisign secret key\n" +
+		"RWRQhGcHOBlzwxrJCyuC+rJfHSfyRKRxkuwa3JJ0bWEs7RHjL1OUmqnTr+V1B9JzFuJIH/ybR2Eus9oEZKt9RbitpF/L4D3+5wg=\n" +
+		"trusted comment: timestamp:1614549543\tfile:message.txt\n" +
+		"P/722+ynQ+tIy0qadFHwLx5MsyNz/jDKJkDWQj4dDD2OKnVte8m/M14mwPE/1NMwzShPMSBhMXqZGdbe+UZjDg==\n"
+)
+
+func TestParseMinisignKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		wantID string
+		ok     bool
+	}{
+		{"base64 line", testPublicKey, testPublicKeyID, true},
+		{"public key file", otherPublicKeyFile, otherPublicKeyID, true},
+		{"surrounding whitespace", "  " + testPublicKey + "\n\n", testPublicKeyID, true},
+		{"not base64", "not a key", "", false},
+		{"wrong length", base64.StdEncoding.EncodeToString([]byte("Ed12345678")), "", false},
+		{"wrong algorithm", replaceAlgorithm(testPublicKey, "ED"), "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := parseMinisignKey(test.value)
+			if (err == nil) != test.ok {
+				t.Fatalf("parseMinisignKey() error = %v, want ok = %v", err, test.ok)
+			}
+			if test.ok && formatKeyID(key.id) != test.wantID {
+				t.Errorf("key id = %s, want %s", formatKeyID(key.id), test.wantID)
+			}
+		})
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	keys := []minisignKey{mustParseKey(t, testPublicKey), mustParseKey(t, otherPublicKeyFile)}
+
+	tests := []struct {
+		name      string
+		content   string
+		signature string
+		keys      []minisignKey
+		wantID    string
+		wantErr   string
+	}{
+		{"legacy signature", testContent, testLegacySignature, keys, testPublicKeyID, ""},
+		{"prehashed signature", testContent, testPrehashedSignature, keys, testPublicKeyID, ""},
+		{"second trusted key", otherContent, otherSignature, keys, otherPublicKeyID, ""},
+		{"CRLF line endings", testContent, strings.ReplaceAll(testPrehashedSignature, "\n", "\r\n"), keys, testPublicKeyID, ""},
+
+		{"tampered content", "tesT", testLegacySignature, keys, "", "invalid signature"},
+		{"tampered prehashed content", "test\n", testPrehashedSignature, keys, "", "invalid signature"},
+		{"tampered signature", testContent, tamperLine(testLegacySignature, 1, 20), keys, "", "invalid signature"},
+		{"algorithm changed to prehashed", testContent, replaceSignatureAlgorithm(testLegacySignature, "ED"), keys, "", "invalid signature"},
+		{"algorithm changed to legacy", testContent, replaceSignatureAlgorithm(testPrehashedSignature, "Ed"), keys, "", "invalid signature"},
+		{"tampered trusted comment", testContent, strings.Replace(testLegacySignature, "timestamp:1635442742", "timestamp:1635442743", 1), keys, "", "invalid trusted comment signature"},
+		{"tampered global signature", testContent, tamperLine(testLegacySignature, 3, 5), keys, "", "invalid trusted comment signature"},
+		{"trusted comment of another signature", testContent, swapTrustedComment(testLegacySignature, testPrehashedSignature), keys, "", "invalid trusted comment signature"},
+
+		{"untrusted key", testContent, testLegacySignature, keys[1:], "", "signed by untrusted key " + testPublicKeyID},
+		{"no keys", testContent, testLegacySignature, nil, "", "untrusted key"},
+		{"unsupported algorithm", testContent, replaceSignatureAlgorithm(testLegacySignature, "Xx"), keys, "", "unsupported signature algorithm"},
+		{"missing lines", testContent, "untrusted comment: x\n" + strings.Split(testLegacySignature, "\n")[1], keys, "", "malformed signature file"},
+		{"signature not base64", testContent, strings.Replace(testLegacySignature, "RWQf", "!!!!", 1), keys, "", "malformed signature"},
+		{"missing trusted comment prefix", testContent, strings.Replace(testLegacySignature, "\ntrusted comment: ", "\ncomment: ", 1), keys, "", "malformed trusted comment"},
+		{"short global signature", testContent, strings.Replace(testLegacySignature, "0YteLgV960ia", "", 1), keys, "", "malformed global signature"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyID, err := verifyMinisign([]byte(test.content), []byte(test.signature), test.keys)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("verifyMinisign() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyMinisign() error = %v", err)
+			}
+			if keyID != test.wantID {
+				t.Errorf("verifyMinisign() = %s, want %s", keyID, test.wantID)
+			}
+		})
+	}
+}
+
+func TestVerifyPlugin(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "daiv-test")
+	if err := os.WriteFile(path, []byte(testContent), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(testContent))
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name             string
+		expectedChecksum string
+		published        string
+		signature        string
+		trustedKeys      []string
+		requireSignature bool
+		wantID           string
+		wantErr          string
+	}{
+		{name: "unsigned"},
+		{name: "expected checksum", expectedChecksum: "sha256:" + strings.ToUpper(checksum)},
+		{name: "wrong expected checksum", expectedChecksum: strings.Repeat("0", 64), wantErr: "checksum mismatch"},
+		{name: "published checksum", published: checksum + "  daiv-test\n"},
+		{name: "wrong published checksum", published: strings.Repeat("a", 64) + "  daiv-test\n", wantErr: "checksum mismatch"},
+		{name: "signed by a trusted key", signature: testPrehashedSignature, trustedKeys: []string{testPublicKey}, wantID: testPublicKeyID},
+		{name: "signed by an untrusted key", signature: testPrehashedSignature, trustedKeys: []string{otherPublicKeyFile}, wantErr: "untrusted key"},
+		{name: "tampered signature", signature: tamperLine(testPrehashedSignature, 1, 30), trustedKeys: []string{testPublicKey}, wantErr: "signature verification failed"},
+		{name: "required signature missing", trustedKeys: []string{testPublicKey}, requireSignature: true, wantErr: "not signed"},
+		{name: "required signature without trusted keys", signature: testPrehashedSignature, requireSignature: true, wantErr: "no plugins.trustedKeys"},
+		{name: "invalid trusted key", signature: testPrehashedSignature, trustedKeys: []string{"nope"}, wantErr: "invalid key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Set("plugins.trustedKeys", test.trustedKeys)
+			viper.Set("plugins.requireSignature", test.requireSignature)
+
+			siblings := map[string]string{checksumSuffix: test.published, signatureSuffix: test.signature}
+			sibling := func(suffix string) ([]byte, error) {
+				if siblings[suffix] == "" {
+					return nil, nil
+				}
+				return []byte(siblings[suffix]), nil
+			}
+
+			keyID, err := verifyPlugin(path, test.expectedChecksum, sibling)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("verifyPlugin() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyPlugin() error = %v", err)
+			}
+			if keyID != test.wantID {
+				t.Errorf("verifyPlugin() = %q, want %q", keyID, test.wantID)
+			}
+		})
+	}
+}
+
+func mustParseKey(t *testing.T, value string) minisignKey {
+	t.Helper()
+	key, err := parseMinisignKey(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// tamperLine flips a bit in the decoded base64 of a line of a signature file.
+func tamperLine(signature string, line int, offset int) string {
+	lines := strings.Split(signature, "\n")
+	data, _ := base64.StdEncoding.DecodeString(lines[line])
+	data[offset] ^= 1
+	lines[line] = base64.StdEncoding.EncodeToString(data)
+	return strings.Join(lines, "\n")
+}
+
+// replaceSignatureAlgorithm replaces the algorithm of a signature, keeping its key id and signature.
+func replaceSignatureAlgorithm(signature string, algorithm string) string {
+	lines := strings.Split(signature, "\n")
+	lines[1] = replaceAlgorithm(lines[1], algorithm)
+	return strings.Join(lines, "\n")
+}
+
+func replaceAlgorithm(encoded string, algorithm string) string {
+	data, _ := base64.StdEncoding.DecodeString(encoded)
+	copy(data, algorithm)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// swapTrustedComment replaces the trusted comment and global signature of a signature with another's.
+func swapTrustedComment(signature string, other string) string {
+	lines := strings.Split(signature, "\n")
+	otherLines := strings.Split(other, "\n")
+	lines[2], lines[3] = otherLines[2], otherLines[3]
+	return strings.Join(lines, "\n")
+}
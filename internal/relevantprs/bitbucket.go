@@ -0,0 +1,103 @@
+package relevantprs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// bitbucketProvider lists open pull requests of a Bitbucket Server (Data Center) repository.
+type bitbucketProvider struct {
+	baseURL string
+	token   string
+	project string
+	repo    string
+}
+
+type bitbucketPullRequestPage struct {
+	Values        []bitbucketPullRequest `json:"values"`
+	IsLastPage    bool                   `json:"isLastPage"`
+	NextPageStart int                    `json:"nextPageStart"`
+}
+
+type bitbucketPullRequest struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	FromRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"fromRef"`
+	Author struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	} `json:"author"`
+	Links struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func newBitbucketProvider(repoConfig RepositoryConfig) (*bitbucketProvider, error) {
+	if repoConfig.URL == "" {
+		return nil, fmt.Errorf("bitbucket repository %s requires the url of the Bitbucket Server instance", repoConfig.FullName())
+	}
+
+	return &bitbucketProvider{
+		baseURL: strings.TrimSuffix(repoConfig.URL, "/"),
+		token:   viper.GetString("bitbucket.token"),
+		project: url.PathEscape(repoConfig.Owner),
+		repo:    url.PathEscape(repoConfig.Repo),
+	}, nil
+}
+
+func (p *bitbucketProvider) headers() map[string]string {
+	if p.token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + p.token}
+}
+
+func (p *bitbucketProvider) pullRequestsURL() string {
+	return fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests", p.baseURL, p.project, p.repo)
+}
+
+func (p *bitbucketProvider) ListChanges(ctx context.Context) ([]Change, error) {
+	var changes []Change
+
+	for start := 0; ; {
+		endpoint := fmt.Sprintf("%s?state=OPEN&limit=100&start=%d", p.pullRequestsURL(), start)
+
+		var page bitbucketPullRequestPage
+		if _, err := getJSON(ctx, endpoint, p.headers(), &page); err != nil {
+			return nil, err
+		}
+
+		for _, pr := range page.Values {
+			change := Change{
+				Number: pr.ID,
+				Branch: pr.FromRef.DisplayID,
+				Title:  pr.Title,
+				Author: pr.Author.User.Name,
+			}
+			if len(pr.Links.Self) > 0 {
+				change.URL = pr.Links.Self[0].Href
+			}
+			changes = append(changes, change)
+		}
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	return changes, nil
+}
+
+func (p *bitbucketProvider) Diff(ctx context.Context, change Change) (string, error) {
+	return getText(ctx, fmt.Sprintf("%s/%d.diff", p.pullRequestsURL(), change.Number), p.headers())
+}
@@ -0,0 +1,124 @@
+package relevantprs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// RepositoryConfig holds the configuration for each repository.
+// Provider selects the code host (github, gitlab, bitbucket or local) and defaults to
+// local when Path is set and to github otherwise. URL is the base URL of self-hosted
+// GitLab and Bitbucket Server instances. MinScore hides pull requests scored below it
+// when relevance scoring is enabled.
+type RepositoryConfig struct {
+	Provider     string   `mapstructure:"provider"`
+	Owner        string   `mapstructure:"owner"`
+	Repo         string   `mapstructure:"repo"`
+	URL          string   `mapstructure:"url"`
+	Path         string   `mapstructure:"path"`
+	Base         string   `mapstructure:"base"`
+	Remote       string   `mapstructure:"remote"`
+	SystemPrompt string   `mapstructure:"system_prompt"`
+	Keywords     []string `mapstructure:"keywords"`
+	MinScore     int      `mapstructure:"min_score"`
+}
+
+// ProviderName returns the configured provider, applying the defaults.
+func (r RepositoryConfig) ProviderName() string {
+	if r.Provider != "" {
+		return strings.ToLower(r.Provider)
+	}
+	if r.Path != "" {
+		return ProviderLocal
+	}
+	return ProviderGitHub
+}
+
+// IsLocal reports whether the repository is a local clone.
+func (r RepositoryConfig) IsLocal() bool {
+	return r.ProviderName() == ProviderLocal
+}
+
+// FullName returns the repository in owner/repo form, or its name for local clones.
+func (r RepositoryConfig) FullName() string {
+	if r.IsLocal() && r.Owner == "" {
+		if r.Repo != "" {
+			return r.Repo
+		}
+		return filepath.Base(r.Path)
+	}
+
+	return fmt.Sprintf("%s/%s", r.Owner, r.Repo)
+}
+
+// WatchConfig holds the configuration for the watch subcommand.
+type WatchConfig struct {
+	Sinks []SinkConfig `mapstructure:"sinks"`
+}
+
+// Config represents our overall configuration for the command.
+type Config struct {
+	Repositories []RepositoryConfig `mapstructure:"repositories"`
+	Watch        WatchConfig        `mapstructure:"watch"`
+}
+
+// LoadConfig extracts and validates the configuration for relevantPrs.
+func LoadConfig() (Config, error) {
+	sub := viper.Sub("relevantPrs")
+	if sub == nil {
+		return Config{}, fmt.Errorf("no configuration found for relevantPrs; please add a 'relevantPrs' section to your config file")
+	}
+
+	var cfg Config
+	if err := sub.Unmarshal(&cfg); err != nil {
+		return Config{}, fmt.Errorf("error unmarshaling relevantPrs config: %w", err)
+	}
+
+	if len(cfg.Repositories) == 0 {
+		return Config{}, fmt.Errorf("no repositories configured")
+	}
+
+	for i, repository := range cfg.Repositories {
+		if !slices.Contains(Providers, repository.ProviderName()) {
+			return Config{}, fmt.Errorf("repository %d has unknown provider %q, expected one of: %s", i+1, repository.Provider, strings.Join(Providers, ", "))
+		}
+
+		if repository.IsLocal() {
+			if repository.Path == "" {
+				return Config{}, fmt.Errorf("local repository %d needs a path", i+1)
+			}
+
+			path, err := expandHome(repository.Path)
+			if err != nil {
+				return Config{}, err
+			}
+			cfg.Repositories[i].Path = path
+			continue
+		}
+
+		if repository.Owner == "" || repository.Repo == "" {
+			return Config{}, fmt.Errorf("repository %d needs either owner and repo or a local path", i+1)
+		}
+	}
+
+	return cfg, nil
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,203 @@
+package relevantprs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatTable    = "table"
+)
+
+// Formats lists the output formats supported by WriteReport.
+var Formats = []string{FormatText, FormatJSON, FormatMarkdown, FormatTable}
+
+// Report is the outcome of a relevantPrs run: the raw matches of every repository
+// and, when the LLM was used, a summary per repository keyed by its full name.
+type Report struct {
+	Results   []RepositoryResult
+	Summaries map[string]string
+}
+
+// jsonMatch is a flattened match, convenient for consumption by scripts.
+type jsonMatch struct {
+	Repository  string `json:"repository"`
+	PullRequest int    `json:"pullRequest,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	*Relevance
+	ScoreError string `json:"scoreError,omitempty"`
+	LineMatch
+}
+
+type jsonReport struct {
+	Matches   []jsonMatch       `json:"matches"`
+	Summaries map[string]string `json:"summaries,omitempty"`
+}
+
+// WriteReport renders the report in the given format.
+func WriteReport(w io.Writer, format string, report Report) error {
+	switch format {
+	case FormatText, "":
+		return writeText(w, report)
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatMarkdown:
+		return writeMarkdown(w, report)
+	case FormatTable:
+		return writeTable(w, report)
+	default:
+		return fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writeText prints the LLM summaries, falling back to the plain text report for
+// repositories that were not summarized.
+func writeText(w io.Writer, report Report) error {
+	for _, result := range report.Results {
+		if summary, ok := report.Summaries[result.Repository.FullName()]; ok {
+			if summary != "" {
+				fmt.Fprintln(w, summary)
+			}
+			continue
+		}
+
+		if len(result.PullRequests) > 0 {
+			fmt.Fprint(w, BuildReport(result))
+		}
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	output := jsonReport{
+		Matches:   []jsonMatch{},
+		Summaries: report.Summaries,
+	}
+
+	for _, result := range report.Results {
+		for _, pr := range result.PullRequests {
+			for _, match := range pr.Matches {
+				output.Matches = append(output.Matches, jsonMatch{
+					Repository:  result.Repository.FullName(),
+					PullRequest: pr.Number,
+					Branch:      pr.Branch,
+					Title:       pr.Title,
+					URL:         pr.URL,
+					Relevance:   pr.Relevance,
+					ScoreError:  pr.ScoreError,
+					LineMatch:   match,
+				})
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+func writeMarkdown(w io.Writer, report Report) error {
+	for _, result := range report.Results {
+		if len(result.PullRequests) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "## %s\n\n", result.Repository.FullName())
+
+		for _, pr := range result.PullRequests {
+			if pr.URL != "" {
+				fmt.Fprintf(w, "### [%s %s](%s)\n\n", pr.Ref(), pr.Title, pr.URL)
+			} else {
+				fmt.Fprintf(w, "### %s %s\n\n", pr.Ref(), pr.Title)
+			}
+			if pr.Relevance != nil {
+				fmt.Fprintf(w, "**Relevance: %d/%d** - %s\n\n", pr.Relevance.Score, maxRelevanceScore, pr.Relevance.Reason)
+			} else if pr.ScoreError != "" {
+				fmt.Fprintf(w, "**Relevance: not scored** - %s\n\n", pr.ScoreError)
+			}
+			fmt.Fprintln(w, "| File | Line | Keyword | Change |")
+			fmt.Fprintln(w, "| --- | --- | --- | --- |")
+			for _, match := range pr.Matches {
+				fmt.Fprintf(w, "| `%s` | %d | %s | `%s` |\n",
+					match.File,
+					match.Line,
+					match.Keyword,
+					strings.ReplaceAll(strings.TrimSpace(match.Text), "|", "\\|"),
+				)
+			}
+			fmt.Fprintln(w)
+		}
+
+		if summary := report.Summaries[result.Repository.FullName()]; summary != "" {
+			fmt.Fprintf(w, "### Summary\n\n%s\n\n", summary)
+		}
+	}
+
+	return nil
+}
+
+func writeTable(w io.Writer, report Report) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "REPOSITORY\tPR\tSCORE\tFILE\tLINE\tKEYWORD")
+
+	for _, result := range report.Results {
+		for _, pr := range result.PullRequests {
+			score := "-"
+			if pr.Relevance != nil {
+				score = fmt.Sprintf("%d/%d", pr.Relevance.Score, maxRelevanceScore)
+			} else if pr.ScoreError != "" {
+				score = "unscored"
+			}
+
+			for _, match := range pr.Matches {
+				fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%s\n",
+					result.Repository.FullName(),
+					pr.Ref(),
+					score,
+					match.File,
+					match.Line,
+					match.Keyword,
+				)
+			}
+		}
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	reasonsHeader := false
+	for _, result := range report.Results {
+		for _, pr := range result.PullRequests {
+			if pr.Relevance == nil && pr.ScoreError == "" {
+				continue
+			}
+			if !reasonsHeader {
+				fmt.Fprintln(w)
+				reasonsHeader = true
+			}
+			if pr.Relevance == nil {
+				fmt.Fprintf(w, "%s %s (not scored): %s\n", result.Repository.FullName(), pr.Ref(), pr.ScoreError)
+				continue
+			}
+			fmt.Fprintf(w, "%s %s (%d/%d): %s\n", result.Repository.FullName(), pr.Ref(), pr.Relevance.Score, maxRelevanceScore, pr.Relevance.Reason)
+		}
+	}
+
+	for _, result := range report.Results {
+		if summary := report.Summaries[result.Repository.FullName()]; summary != "" {
+			fmt.Fprintf(w, "\n%s:\n%s\n", result.Repository.FullName(), summary)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package relevantprs
+
+import (
+	"context"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// githubProvider lists open pull requests of a GitHub repository.
+type githubProvider struct {
+	client *github.Client
+	owner  string
+	repo   string
+}
+
+func (p *githubProvider) ListChanges(ctx context.Context) ([]Change, error) {
+	prList, err := listAllPRs(ctx, p.client, p.owner, p.repo, &github.PullRequestListOptions{
+		State: "open",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, len(prList))
+	for i, pr := range prList {
+		changes[i] = Change{
+			Number: pr.GetNumber(),
+			Branch: pr.GetHead().GetRef(),
+			Title:  pr.GetTitle(),
+			URL:    pr.GetHTMLURL(),
+			Author: pr.GetUser().GetLogin(),
+		}
+	}
+
+	return changes, nil
+}
+
+func (p *githubProvider) Diff(ctx context.Context, change Change) (string, error) {
+	diff, _, err := p.client.PullRequests.GetRaw(ctx, p.owner, p.repo, change.Number, github.RawOptions{Type: github.Diff})
+	return diff, err
+}
+
+// listAllPRs collects all pull requests with pagination support.
+func listAllPRs(ctx context.Context, client *github.Client, owner, repo string, options *github.PullRequestListOptions) ([]*github.PullRequest, error) {
+	var allPRs []*github.PullRequest
+	opts := *options // create a copy so as not to modify the original options
+	opts.ListOptions = github.ListOptions{PerPage: 100}
+
+	for {
+		prs, resp, err := client.PullRequests.List(ctx, owner, repo, &opts)
+		if err != nil {
+			return nil, err
+		}
+		allPRs = append(allPRs, prs...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allPRs, nil
+}
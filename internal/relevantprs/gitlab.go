@@ -0,0 +1,121 @@
+package relevantprs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const defaultGitLabURL = "https://gitlab.com"
+
+// gitlabProvider lists open merge requests of a GitLab project.
+type gitlabProvider struct {
+	baseURL string
+	token   string
+	project string
+}
+
+type gitlabMergeRequest struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	WebURL       string `json:"web_url"`
+	SourceBranch string `json:"source_branch"`
+	Author       struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+type gitlabDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+func newGitLabProvider(repoConfig RepositoryConfig) *gitlabProvider {
+	baseURL := repoConfig.URL
+	if baseURL == "" {
+		baseURL = defaultGitLabURL
+	}
+
+	return &gitlabProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   viper.GetString("gitlab.token"),
+		project: url.PathEscape(repoConfig.Owner + "/" + repoConfig.Repo),
+	}
+}
+
+func (p *gitlabProvider) headers() map[string]string {
+	if p.token == "" {
+		return nil
+	}
+	return map[string]string{"PRIVATE-TOKEN": p.token}
+}
+
+func (p *gitlabProvider) ListChanges(ctx context.Context) ([]Change, error) {
+	var changes []Change
+
+	for page := "1"; page != ""; {
+		endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests?state=opened&per_page=100&page=%s", p.baseURL, p.project, page)
+
+		var mergeRequests []gitlabMergeRequest
+		resp, err := getJSON(ctx, endpoint, p.headers(), &mergeRequests)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mr := range mergeRequests {
+			changes = append(changes, Change{
+				Number: mr.IID,
+				Branch: mr.SourceBranch,
+				Title:  mr.Title,
+				URL:    mr.WebURL,
+				Author: mr.Author.Username,
+			})
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return changes, nil
+}
+
+// Diff rebuilds a unified diff from the per-file diffs of the merge request, which
+// GitLab returns without the git headers.
+func (p *gitlabProvider) Diff(ctx context.Context, change Change) (string, error) {
+	var diff strings.Builder
+
+	for page := "1"; page != ""; {
+		endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%d/diffs?per_page=100&page=%s", p.baseURL, p.project, change.Number, page)
+
+		var fileDiffs []gitlabDiff
+		resp, err := getJSON(ctx, endpoint, p.headers(), &fileDiffs)
+		if err != nil {
+			return "", err
+		}
+
+		for _, fileDiff := range fileDiffs {
+			oldPath, newPath := "a/"+fileDiff.OldPath, "b/"+fileDiff.NewPath
+			fmt.Fprintf(&diff, "diff --git %s %s\n", oldPath, newPath)
+			if fileDiff.NewFile {
+				oldPath = "/dev/null"
+			}
+			if fileDiff.DeletedFile {
+				newPath = "/dev/null"
+			}
+			fmt.Fprintf(&diff, "--- %s\n+++ %s\n", oldPath, newPath)
+			diff.WriteString(fileDiff.Diff)
+			if !strings.HasSuffix(fileDiff.Diff, "\n") {
+				diff.WriteString("\n")
+			}
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return diff.String(), nil
+}
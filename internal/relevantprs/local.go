@@ -0,0 +1,136 @@
+package relevantprs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	defaultLocalBase   = "main"
+	defaultLocalRemote = "origin"
+)
+
+// localProvider treats the remote branches of a local clone that are not merged
+// into the base branch like open pull requests.
+type localProvider struct {
+	path   string
+	remote string
+	base   string
+
+	// resolvedBase is the ref branches are compared against, set by ListChanges.
+	resolvedBase string
+}
+
+func newLocalProvider(repoConfig RepositoryConfig) *localProvider {
+	remote := repoConfig.Remote
+	if remote == "" {
+		remote = defaultLocalRemote
+	}
+
+	return &localProvider{
+		path:   repoConfig.Path,
+		remote: remote,
+		base:   repoConfig.Base,
+	}
+}
+
+func (p *localProvider) ListChanges(ctx context.Context) ([]Change, error) {
+	base, err := resolveLocalBase(ctx, p.path, p.remote, p.base)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving base branch: %w", err)
+	}
+	p.resolvedBase = base
+
+	branches, err := listUnmergedBranches(ctx, p.path, p.remote, base)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, len(branches))
+	for i, branch := range branches {
+		changes[i] = Change{Branch: branch}
+
+		// The subject and author of the latest commit stand in for the PR metadata.
+		if output, err := runGit(ctx, p.path, "log", "-1", "--format=%s%n%an", branch); err == nil {
+			title, author, _ := strings.Cut(strings.TrimSpace(output), "\n")
+			changes[i].Title = title
+			changes[i].Author = author
+		}
+	}
+
+	return changes, nil
+}
+
+func (p *localProvider) Diff(ctx context.Context, change Change) (string, error) {
+	if p.resolvedBase == "" {
+		base, err := resolveLocalBase(ctx, p.path, p.remote, p.base)
+		if err != nil {
+			return "", fmt.Errorf("error resolving base branch: %w", err)
+		}
+		p.resolvedBase = base
+	}
+
+	// Three dots: only the changes introduced on the branch since it forked from base.
+	return runGit(ctx, p.path, "diff", p.resolvedBase+"..."+change.Branch)
+}
+
+// resolveLocalBase returns the ref to compare branches against, preferring the
+// remote-tracking branch so that a stale local base does not produce false matches.
+func resolveLocalBase(ctx context.Context, path, remote, base string) (string, error) {
+	if base == "" {
+		base = defaultLocalBase
+	}
+
+	for _, ref := range []string{remote + "/" + base, base} {
+		if _, err := runGit(ctx, path, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			return ref, nil
+		}
+	}
+
+	return "", fmt.Errorf("branch %s not found", base)
+}
+
+// listUnmergedBranches returns the remote branches that have commits not in base.
+func listUnmergedBranches(ctx context.Context, path, remote, base string) ([]string, error) {
+	output, err := runGit(ctx, path, "for-each-ref", "--format=%(refname:short)", "refs/remotes/"+remote)
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []string
+	for _, branch := range strings.Fields(output) {
+		if branch == base || branch == remote+"/HEAD" || branch == remote {
+			continue
+		}
+
+		count, err := runGit(ctx, path, "rev-list", "--count", base+".."+branch)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(count) == "0" {
+			continue
+		}
+
+		branches = append(branches, branch)
+	}
+
+	return branches, nil
+}
+
+// runGit runs a git command in the given repository and returns its output.
+func runGit(ctx context.Context, path string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", path}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
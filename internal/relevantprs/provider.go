@@ -0,0 +1,126 @@
+package relevantprs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	internalGithub "daiv/internal/github"
+)
+
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderBitbucket = "bitbucket"
+	ProviderLocal     = "local"
+)
+
+// Providers lists the code hosts supported in RepositoryConfig.Provider.
+var Providers = []string{ProviderGitHub, ProviderGitLab, ProviderBitbucket, ProviderLocal}
+
+// Change is an open change on a code host: a pull request, a merge request or,
+// for local repositories, an unmerged branch identified by Branch instead of Number.
+type Change struct {
+	Number int
+	Branch string
+	Title  string
+	URL    string
+	Author string
+}
+
+// Ref returns a short reference to the change: #number, or the branch name.
+func (c Change) Ref() string {
+	if c.Number == 0 && c.Branch != "" {
+		return c.Branch
+	}
+	return fmt.Sprintf("#%d", c.Number)
+}
+
+// Provider gives access to the open changes of a single repository on a code host.
+type Provider interface {
+	// ListChanges returns the open changes of the repository.
+	ListChanges(ctx context.Context) ([]Change, error)
+	// Diff returns the unified diff of a change.
+	Diff(ctx context.Context, change Change) (string, error)
+}
+
+// NewProvider creates the provider selected by the repository configuration.
+func NewProvider(repoConfig RepositoryConfig) (Provider, error) {
+	switch repoConfig.ProviderName() {
+	case ProviderGitHub:
+		client, err := internalGithub.NewGithubClient()
+		if err != nil {
+			return nil, fmt.Errorf("error creating github client: %w", err)
+		}
+		return &githubProvider{client: client, owner: repoConfig.Owner, repo: repoConfig.Repo}, nil
+	case ProviderGitLab:
+		return newGitLabProvider(repoConfig), nil
+	case ProviderBitbucket:
+		return newBitbucketProvider(repoConfig)
+	case ProviderLocal:
+		return newLocalProvider(repoConfig), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", repoConfig.Provider)
+	}
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// getHTTP performs an authenticated GET request and returns the response, failing
+// on non-2xx statuses.
+func getHTTP(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: HTTP status %d", url, resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into target.
+func getJSON(ctx context.Context, url string, headers map[string]string, target any) (*http.Response, error) {
+	resp, err := getHTTP(ctx, url, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+
+	return resp, nil
+}
+
+// getText performs a GET request and returns the response body as text.
+func getText(ctx context.Context, url string, headers map[string]string) (string, error) {
+	resp, err := getHTTP(ctx, url, headers)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response from %s: %w", url, err)
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,56 @@
+package relevantprs
+
+import (
+	"daiv/internal/llm"
+	"fmt"
+	"strings"
+)
+
+// BuildReport renders the matches of a repository as plain text.
+func BuildReport(result RepositoryResult) string {
+	var report strings.Builder
+
+	fmt.Fprintf(&report, "Repository: %s\n", result.Repository.FullName())
+
+	for _, pr := range result.PullRequests {
+		fmt.Fprintf(&report, "  (PR %s)[%s]: \n  %s\n", pr.Ref(), pr.URL, pr.Title)
+
+		if pr.Relevance != nil {
+			fmt.Fprintf(&report, "    Relevance: %d/%d - %s\n", pr.Relevance.Score, maxRelevanceScore, pr.Relevance.Reason)
+		} else if pr.ScoreError != "" {
+			fmt.Fprintf(&report, "    Relevance: not scored - %s\n", pr.ScoreError)
+		}
+
+		fmt.Fprintln(&report, "    Matched changes:")
+		for _, match := range pr.Matches {
+			fmt.Fprintf(&report, "      %s\n", match.Text)
+		}
+	}
+
+	fmt.Fprintln(&report, "")
+
+	return report.String()
+}
+
+// Summarize asks the LLM to summarize the matches of a repository using its system prompt.
+func Summarize(result RepositoryResult) (string, error) {
+	if len(result.PullRequests) == 0 {
+		return "", nil
+	}
+
+	llmClient, err := llm.NewClient()
+	if err != nil {
+		return "", fmt.Errorf("error creating LLM client: %w", err)
+	}
+
+	var prompt strings.Builder
+
+	fmt.Fprintf(&prompt, "System prompt: %s\n %s", result.Repository.SystemPrompt, BuildReport(result))
+
+	completion, err := llmClient.GenerateFromSinglePrompt(prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("error generating completion: %w", err)
+	}
+
+	return completion, nil
+}
@@ -0,0 +1,204 @@
+package relevantprs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PullRequestMatch is an open change whose diff matched at least one keyword.
+// Relevance is only set when the matches were scored by the LLM.
+type PullRequestMatch struct {
+	Change
+	Matches   []LineMatch
+	Relevance *Relevance
+	// ScoreError is set when the LLM failed to score the pull request.
+	ScoreError string
+}
+
+// RepositoryResult holds the matching pull requests found in a single repository.
+type RepositoryResult struct {
+	Repository   RepositoryConfig
+	PullRequests []PullRequestMatch
+}
+
+// LineMatch is a single diff line that contains one of the configured keywords.
+// Line is the line number in the new version of the file, or in the old version for
+// removed lines. It is zero for matches in file headers.
+type LineMatch struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Keyword string `json:"keyword"`
+	Text    string `json:"text"`
+}
+
+// findKeywordMatches scans the diff text and returns any lines that match one or more keywords (case-insensitive)
+func findKeywordMatches(diffStr string, keywords []string) []LineMatch {
+	var matches []LineMatch
+	// Precompute lower-case keywords to avoid repetition.
+	lowerKeywords := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowerKeywords[i] = strings.ToLower(keyword)
+	}
+
+	var file string
+	var oldLine, newLine int
+	// oldLeft and newLeft are the lines left in the current hunk. File headers only
+	// appear outside hunks, so a removed "-- comment" line is not taken for one.
+	var oldLeft, newLeft int
+
+	for _, line := range strings.Split(diffStr, "\n") {
+		lineNumber := 0
+
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				lineNumber = newLine
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				lineNumber = oldLine
+				oldLine++
+				oldLeft--
+			case strings.HasPrefix(line, " "), line == "":
+				lineNumber = newLine
+				oldLine++
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+		} else {
+			switch {
+			case strings.HasPrefix(line, "diff --git "):
+				file = diffHeaderFile(line)
+			case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "index "):
+				continue
+			case strings.HasPrefix(line, "+++ "):
+				if path := strings.TrimPrefix(line, "+++ "); path != "/dev/null" {
+					file = strings.TrimPrefix(path, "b/")
+				}
+				continue
+			case strings.HasPrefix(line, "@@"):
+				oldLine, oldLeft, newLine, newLeft = parseHunkHeader(line)
+				continue
+			}
+		}
+
+		lowerLine := strings.ToLower(line)
+		for i, lowerKeyword := range lowerKeywords {
+			if strings.Contains(lowerLine, lowerKeyword) {
+				matches = append(matches, LineMatch{
+					File:    file,
+					Line:    lineNumber,
+					Keyword: keywords[i],
+					Text:    line,
+				})
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// diffHeaderFile extracts the new file path from a "diff --git a/x b/y" header.
+func diffHeaderFile(header string) string {
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+len(" b/"):]
+	}
+	return ""
+}
+
+// parseHunkHeader returns the starting line and line count of the old and new side of a
+// "@@ -a,b +c,d @@" header. A count that is left out is 1.
+func parseHunkHeader(header string) (oldStart, oldCount, newStart, newCount int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0, 0, 0
+	}
+
+	oldStart, oldCount = parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+	newStart, newCount = parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	return oldStart, oldCount, newStart, newCount
+}
+
+// parseHunkRange parses the "start,count" of one side of a hunk header.
+func parseHunkRange(hunkRange string) (int, int) {
+	start, count, found := strings.Cut(hunkRange, ",")
+	startLine, _ := strconv.Atoi(start)
+	if !found {
+		return startLine, 1
+	}
+
+	lineCount, _ := strconv.Atoi(count)
+	return startLine, lineCount
+}
+
+// ScanRepository lists the open changes of a repository through its provider and
+// returns the ones whose diff contains any of the configured keywords.
+func ScanRepository(ctx context.Context, repoConfig RepositoryConfig) (RepositoryResult, error) {
+	result := RepositoryResult{Repository: repoConfig}
+
+	provider, err := NewProvider(repoConfig)
+	if err != nil {
+		return result, fmt.Errorf("error creating provider for %s: %w", repoConfig.FullName(), err)
+	}
+
+	changes, err := provider.ListChanges(ctx)
+	if err != nil {
+		return result, fmt.Errorf("error listing PRs for %s: %w", repoConfig.FullName(), err)
+	}
+
+	for _, change := range changes {
+		diff, err := provider.Diff(ctx, change)
+		if err != nil {
+			log.Printf("Error getting diff for %s %s: %v", repoConfig.FullName(), change.Ref(), err)
+			continue
+		}
+
+		matches := findKeywordMatches(diff, repoConfig.Keywords)
+		if len(matches) == 0 {
+			continue
+		}
+
+		result.PullRequests = append(result.PullRequests, PullRequestMatch{
+			Change:  change,
+			Matches: matches,
+		})
+	}
+
+	return result, nil
+}
+
+// ScanRepositories scans all repositories concurrently. Results are returned in the
+// same order as the given repositories; repositories that fail are logged and skipped.
+func ScanRepositories(ctx context.Context, repositories []RepositoryConfig) []RepositoryResult {
+	results := make([]*RepositoryResult, len(repositories))
+
+	var wg sync.WaitGroup
+	for i, repoConfig := range repositories {
+		wg.Add(1)
+		go func(i int, repoConfig RepositoryConfig) {
+			defer wg.Done()
+			result, err := ScanRepository(ctx, repoConfig)
+			if err != nil {
+				log.Print(err)
+				return
+			}
+			results[i] = &result
+		}(i, repoConfig)
+	}
+	wg.Wait()
+
+	var scanned []RepositoryResult
+	for _, result := range results {
+		if result != nil {
+			scanned = append(scanned, *result)
+		}
+	}
+
+	return scanned
+}
@@ -0,0 +1,150 @@
+package relevantprs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseHunkHeader(t *testing.T) {
+	tests := []struct {
+		header                                 string
+		oldStart, oldCount, newStart, newCount int
+	}{
+		{"@@ -1,5 +1,6 @@", 1, 5, 1, 6},
+		{"@@ -10,3 +12,4 @@ func main() {", 10, 3, 12, 4},
+		{"@@ -7 +7 @@", 7, 1, 7, 1},
+		{"@@ -0,0 +1,3 @@", 0, 0, 1, 3},
+		{"@@ -1,2 +0,0 @@", 1, 2, 0, 0},
+		{"@@", 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			oldStart, oldCount, newStart, newCount := parseHunkHeader(test.header)
+			if oldStart != test.oldStart || oldCount != test.oldCount || newStart != test.newStart || newCount != test.newCount {
+				t.Errorf("parseHunkHeader(%q) = %d, %d, %d, %d, want %d, %d, %d, %d", test.header,
+					oldStart, oldCount, newStart, newCount,
+					test.oldStart, test.oldCount, test.newStart, test.newCount)
+			}
+		})
+	}
+}
+
+func TestFindKeywordMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		keywords []string
+		want     []LineMatch
+	}{
+		{
+			name: "added, removed and context lines",
+			diff: `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,4 @@ func main() {
+ 	setup()
+-	oldPayment()
++	newPayment()
+ 	teardown()
+`,
+			keywords: []string{"payment", "teardown"},
+			want: []LineMatch{
+				{File: "main.go", Line: 11, Keyword: "payment", Text: "-	oldPayment()"},
+				{File: "main.go", Line: 11, Keyword: "payment", Text: "+	newPayment()"},
+				{File: "main.go", Line: 12, Keyword: "teardown", Text: " 	teardown()"},
+			},
+		},
+		{
+			name: "lines that look like file headers inside a hunk",
+			diff: `diff --git a/schema.sql b/schema.sql
+--- a/schema.sql
++++ b/schema.sql
+@@ -1,3 +1,3 @@
+--- old billing comment
++++ new billing comment
+ SELECT 1;
+ SELECT billing;
+`,
+			keywords: []string{"billing"},
+			want: []LineMatch{
+				{File: "schema.sql", Line: 1, Keyword: "billing", Text: "--- old billing comment"},
+				{File: "schema.sql", Line: 1, Keyword: "billing", Text: "+++ new billing comment"},
+				{File: "schema.sql", Line: 3, Keyword: "billing", Text: " SELECT billing;"},
+			},
+		},
+		{
+			name: "several files and hunks",
+			diff: `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-Token
++token
+@@ -20,2 +20,3 @@
+ x
++TOKEN here
+ y
+diff --git a/b.txt b/b.txt
+new file mode 100644
+--- /dev/null
++++ b/b.txt
+@@ -0,0 +1,2 @@
++first
++second token
+`,
+			keywords: []string{"token"},
+			want: []LineMatch{
+				{File: "a.txt", Line: 1, Keyword: "token", Text: "-Token"},
+				{File: "a.txt", Line: 1, Keyword: "token", Text: "+token"},
+				{File: "a.txt", Line: 21, Keyword: "token", Text: "+TOKEN here"},
+				{File: "b.txt", Line: 2, Keyword: "token", Text: "+second token"},
+			},
+		},
+		{
+			name: "deleted file",
+			diff: `diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-keep
+-secret
+`,
+			keywords: []string{"secret"},
+			want: []LineMatch{
+				{File: "old.txt", Line: 2, Keyword: "secret", Text: "-secret"},
+			},
+		},
+		{
+			name: "matches in the file header",
+			diff: `diff --git a/payments/api.go b/payments/api.go
+--- a/payments/api.go
++++ b/payments/api.go
+@@ -1 +1 @@
+-a
++b
+`,
+			keywords: []string{"payments"},
+			want: []LineMatch{
+				{File: "payments/api.go", Line: 0, Keyword: "payments", Text: "diff --git a/payments/api.go b/payments/api.go"},
+			},
+		},
+		{
+			name:     "no keywords",
+			diff:     "diff --git a/a b/a\n@@ -1 +1 @@\n-x\n+y\n",
+			keywords: nil,
+			want:     nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := findKeywordMatches(test.diff, test.keywords)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("findKeywordMatches() =\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
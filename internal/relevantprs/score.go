@@ -0,0 +1,93 @@
+package relevantprs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const maxRelevanceScore = 10
+
+// Relevance is the LLM's assessment of how relevant a pull request is to the reviewer.
+type Relevance struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// Completer generates a completion for a single prompt, such as *llm.Client.
+type Completer interface {
+	GenerateFromSinglePrompt(prompt string) (string, error)
+}
+
+// ScoreResult asks the LLM to score every matched pull request of the result, drops the
+// ones below the repository's MinScore and sorts the rest by descending score. Pull
+// requests the LLM failed to score are kept after the scored ones, with ScoreError set,
+// so that a flaky response doesn't hide a match.
+func ScoreResult(llmClient Completer, result *RepositoryResult) {
+	var scored, unscored []PullRequestMatch
+
+	for _, pr := range result.PullRequests {
+		relevance, err := scorePullRequest(llmClient, result.Repository, pr)
+		if err != nil {
+			log.Printf("Error scoring %s %s: %v", result.Repository.FullName(), pr.Ref(), err)
+			pr.ScoreError = err.Error()
+			unscored = append(unscored, pr)
+			continue
+		}
+
+		if relevance.Score < result.Repository.MinScore {
+			continue
+		}
+
+		pr.Relevance = relevance
+		scored = append(scored, pr)
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Relevance.Score > scored[j].Relevance.Score
+	})
+
+	result.PullRequests = append(scored, unscored...)
+}
+
+func scorePullRequest(llmClient Completer, repository RepositoryConfig, pr PullRequestMatch) (*Relevance, error) {
+	var prompt strings.Builder
+
+	fmt.Fprintf(&prompt, "System prompt: %s\n\n", repository.SystemPrompt)
+	fmt.Fprintf(&prompt, "Rate how relevant the following pull request is to me, from 0 (not relevant at all) to %d (I must review it).\n", maxRelevanceScore)
+	fmt.Fprintln(&prompt, `Respond only with a JSON object like {"score": 7, "reason": "one line explaining the score"} and nothing else.`)
+	fmt.Fprintf(&prompt, "\nRepository: %s\nPull request %s: %s\n", repository.FullName(), pr.Ref(), pr.Title)
+	fmt.Fprintln(&prompt, "Matched changes:")
+	for _, match := range pr.Matches {
+		fmt.Fprintf(&prompt, "  %s:%d %s\n", match.File, match.Line, match.Text)
+	}
+
+	completion, err := llmClient.GenerateFromSinglePrompt(prompt.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRelevance(completion)
+}
+
+// parseRelevance extracts the JSON object from the completion, tolerating any text
+// the model adds around it.
+func parseRelevance(completion string) (*Relevance, error) {
+	start := strings.Index(completion, "{")
+	end := strings.LastIndex(completion, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in response: %q", completion)
+	}
+
+	var relevance Relevance
+	if err := json.Unmarshal([]byte(completion[start:end+1]), &relevance); err != nil {
+		return nil, fmt.Errorf("invalid relevance response: %w", err)
+	}
+
+	relevance.Score = max(0, min(relevance.Score, maxRelevanceScore))
+	relevance.Reason = strings.TrimSpace(relevance.Reason)
+
+	return &relevance, nil
+}
@@ -0,0 +1,144 @@
+package relevantprs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubScorer answers with the response for the title found in the prompt.
+type stubScorer struct {
+	responses map[string]string
+	errors    map[string]error
+}
+
+func (s *stubScorer) GenerateFromSinglePrompt(prompt string) (string, error) {
+	for title, err := range s.errors {
+		if strings.Contains(prompt, title) {
+			return "", err
+		}
+	}
+	for title, response := range s.responses {
+		if strings.Contains(prompt, title) {
+			return response, nil
+		}
+	}
+	return "", errors.New("unexpected prompt")
+}
+
+func TestScoreResult(t *testing.T) {
+	scorer := &stubScorer{
+		responses: map[string]string{
+			"Add retry":    `{"score": 4, "reason": "touches sync"}`,
+			"Rewrite auth": `Sure! {"score": 9, "reason": "security"} Hope this helps`,
+			"Fix typo":     `{"score": 1, "reason": "cosmetic"}`,
+			"Odd answer":   `I can't rate this`,
+		},
+		errors: map[string]error{
+			"Bump deps": errors.New("rate limited"),
+		},
+	}
+
+	result := RepositoryResult{
+		Repository: RepositoryConfig{Owner: "acme", Repo: "api", MinScore: 2},
+		PullRequests: []PullRequestMatch{
+			{Change: Change{Number: 1, Title: "Add retry"}},
+			{Change: Change{Number: 2, Title: "Bump deps"}},
+			{Change: Change{Number: 3, Title: "Rewrite auth"}},
+			{Change: Change{Number: 4, Title: "Fix typo"}},
+			{Change: Change{Number: 5, Title: "Odd answer"}},
+		},
+	}
+
+	ScoreResult(scorer, &result)
+
+	var got []string
+	for _, pr := range result.PullRequests {
+		switch {
+		case pr.Relevance != nil:
+			got = append(got, pr.Ref()+" scored")
+		case pr.ScoreError != "":
+			got = append(got, pr.Ref()+" unscored")
+		default:
+			got = append(got, pr.Ref()+" missing")
+		}
+	}
+
+	want := []string{"#3 scored", "#1 scored", "#2 unscored", "#5 unscored"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("ScoreResult() = %v, want %v", got, want)
+	}
+	if result.PullRequests[2].ScoreError != "rate limited" {
+		t.Errorf("ScoreError = %q, want the scorer's error", result.PullRequests[2].ScoreError)
+	}
+}
+
+func TestWriteReportUnscored(t *testing.T) {
+	report := Report{Results: []RepositoryResult{{
+		Repository: RepositoryConfig{Owner: "acme", Repo: "api"},
+		PullRequests: []PullRequestMatch{
+			{
+				Change:    Change{Number: 3, Title: "Rewrite auth"},
+				Matches:   []LineMatch{{File: "auth.go", Line: 1, Keyword: "auth", Text: "+auth"}},
+				Relevance: &Relevance{Score: 9, Reason: "security"},
+			},
+			{
+				Change:     Change{Number: 2, Title: "Bump deps"},
+				Matches:    []LineMatch{{File: "go.mod", Line: 5, Keyword: "auth", Text: "+auth v2"}},
+				ScoreError: "rate limited",
+			},
+		},
+	}}}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatText, []string{"Relevance: 9/10 - security", "Relevance: not scored - rate limited"}},
+		{FormatMarkdown, []string{"**Relevance: 9/10** - security", "**Relevance: not scored** - rate limited"}},
+		{FormatTable, []string{"9/10", "unscored", "acme/api #2 (not scored): rate limited"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := WriteReport(&output, test.format, report); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("output is missing %q:\n%s", want, output.String())
+				}
+			}
+		})
+	}
+
+	t.Run(FormatJSON, func(t *testing.T) {
+		var output bytes.Buffer
+		if err := WriteReport(&output, FormatJSON, report); err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded struct {
+			Matches []struct {
+				PullRequest int    `json:"pullRequest"`
+				Score       *int   `json:"score"`
+				ScoreError  string `json:"scoreError"`
+			} `json:"matches"`
+		}
+		if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.Matches) != 2 {
+			t.Fatalf("got %d matches, want 2", len(decoded.Matches))
+		}
+		if scored := decoded.Matches[0]; scored.Score == nil || *scored.Score != 9 || scored.ScoreError != "" {
+			t.Errorf("scored match = %+v", scored)
+		}
+		if unscored := decoded.Matches[1]; unscored.Score != nil || unscored.ScoreError != "rate limited" {
+			t.Errorf("unscored match = %+v", unscored)
+		}
+	})
+}
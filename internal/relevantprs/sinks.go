@@ -0,0 +1,146 @@
+package relevantprs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	SinkTypeStdout  = "stdout"
+	SinkTypeJSONL   = "jsonl"
+	SinkTypeWebhook = "webhook"
+)
+
+// SinkConfig describes a notification sink in the relevantPrs.watch.sinks config section.
+type SinkConfig struct {
+	Type string `mapstructure:"type"`
+	Path string `mapstructure:"path"`
+	URL  string `mapstructure:"url"`
+}
+
+// Notification is emitted when a pull request starts matching the configured keywords.
+type Notification struct {
+	Time         time.Time `json:"time"`
+	Repository   string    `json:"repository"`
+	Number       int       `json:"number,omitempty"`
+	Branch       string    `json:"branch,omitempty"`
+	Ref          string    `json:"ref"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	MatchedLines []string  `json:"matchedLines"`
+	// Text is a human readable summary, also understood by chat webhooks such as Slack's.
+	Text string `json:"text"`
+}
+
+// NewNotification builds the notification for a newly matching pull request.
+func NewNotification(repository RepositoryConfig, pr PullRequestMatch) Notification {
+	matchedLines := make([]string, len(pr.Matches))
+	for i, match := range pr.Matches {
+		matchedLines[i] = match.Text
+	}
+
+	return Notification{
+		Time:         time.Now(),
+		Repository:   repository.FullName(),
+		Number:       pr.Number,
+		Branch:       pr.Branch,
+		Ref:          pr.Ref(),
+		Title:        pr.Title,
+		URL:          pr.URL,
+		MatchedLines: matchedLines,
+		Text:         notificationText(repository, pr),
+	}
+}
+
+func notificationText(repository RepositoryConfig, pr PullRequestMatch) string {
+	text := fmt.Sprintf("New relevant PR in %s: %s %s", repository.FullName(), pr.Ref(), pr.Title)
+	if pr.URL != "" {
+		text += fmt.Sprintf(" (%s)", pr.URL)
+	}
+	return text
+}
+
+// Sink delivers notifications somewhere.
+type Sink interface {
+	Notify(notification Notification) error
+}
+
+// NewSink creates the sink described by the given configuration.
+func NewSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case SinkTypeStdout, "":
+		return &stdoutSink{logger: log.New(os.Stdout, "", log.LstdFlags)}, nil
+	case SinkTypeJSONL:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("jsonl sink requires a path")
+		}
+		return &jsonlSink{path: cfg.Path}, nil
+	case SinkTypeWebhook:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("webhook sink requires a url")
+		}
+		return &webhookSink{url: cfg.URL, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+// stdoutSink logs notifications to standard output.
+type stdoutSink struct {
+	logger *log.Logger
+}
+
+func (s *stdoutSink) Notify(notification Notification) error {
+	s.logger.Println(notification.Text)
+	return nil
+}
+
+// jsonlSink appends notifications to a file, one JSON document per line.
+type jsonlSink struct {
+	path string
+}
+
+func (s *jsonlSink) Notify(notification Notification) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", s.path, err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(notification)
+}
+
+// webhookSink posts notifications as JSON to an HTTP endpoint.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Notify(notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with HTTP status %d", resp.StatusCode)
+	}
+
+	return nil
+}
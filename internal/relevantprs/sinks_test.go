@@ -0,0 +1,130 @@
+package relevantprs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testNotification() Notification {
+	repository := RepositoryConfig{Owner: "acme", Repo: "api"}
+	return NewNotification(repository, PullRequestMatch{
+		Change:  Change{Number: 12, Title: "Add retry", URL: "https://github.com/acme/api/pull/12"},
+		Matches: []LineMatch{{File: "sync.go", Line: 3, Keyword: "retry", Text: "+retry()"}},
+	})
+}
+
+func TestNewNotification(t *testing.T) {
+	notification := testNotification()
+
+	if notification.Repository != "acme/api" || notification.Ref != "#12" || notification.Title != "Add retry" {
+		t.Errorf("notification = %+v", notification)
+	}
+	if len(notification.MatchedLines) != 1 || notification.MatchedLines[0] != "+retry()" {
+		t.Errorf("MatchedLines = %v, want [+retry()]", notification.MatchedLines)
+	}
+	if want := "New relevant PR in acme/api: #12 Add retry (https://github.com/acme/api/pull/12)"; notification.Text != want {
+		t.Errorf("Text = %q, want %q", notification.Text, want)
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "notifications.jsonl")
+	sink, err := NewSink(SinkConfig{Type: SinkTypeJSONL, Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := testNotification(), testNotification()
+	second.Ref = "#13"
+	for _, notification := range []Notification{first, second} {
+		if err := sink.Notify(notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var refs []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var notification Notification
+		if err := json.Unmarshal(scanner.Bytes(), &notification); err != nil {
+			t.Fatalf("line %q is not a notification: %v", scanner.Text(), err)
+		}
+		refs = append(refs, notification.Ref)
+	}
+	if strings.Join(refs, ",") != "#12,#13" {
+		t.Errorf("appended notifications %v, want #12 and #13", refs)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"accepted", http.StatusOK, false},
+		{"no content", http.StatusNoContent, false},
+		{"server error", http.StatusInternalServerError, true},
+		{"not modified", http.StatusNotModified, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var received Notification
+			var contentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("method = %s, want POST", r.Method)
+				}
+				contentType = r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &received); err != nil {
+					t.Errorf("body %q is not a notification: %v", body, err)
+				}
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			sink, err := NewSink(SinkConfig{Type: SinkTypeWebhook, URL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = sink.Notify(testNotification())
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Notify() error = %v, want error %v", err, test.wantErr)
+			}
+			if contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			if received.Ref != "#12" || received.Text == "" {
+				t.Errorf("received %+v", received)
+			}
+		})
+	}
+}
+
+func TestNewSinkValidation(t *testing.T) {
+	for _, cfg := range []SinkConfig{
+		{Type: SinkTypeJSONL},
+		{Type: SinkTypeWebhook},
+		{Type: "email"},
+	} {
+		if _, err := NewSink(cfg); err == nil {
+			t.Errorf("NewSink(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package relevantprs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Watcher periodically scans repositories and notifies sinks about pull requests
+// that started matching since the previous poll.
+type Watcher struct {
+	repositories []RepositoryConfig
+	sinks        []Sink
+	statePath    string
+	// scan scans the repositories, ScanRepositories unless replaced in tests.
+	scan func(ctx context.Context, repositories []RepositoryConfig) []RepositoryResult
+
+	// NotifyExisting makes the first poll notify about every matching pull request
+	// instead of silently recording them as already seen.
+	NotifyExisting bool
+
+	// seen maps repository names to the refs of their matching pull requests and
+	// the time each one was first seen.
+	seen     map[string]map[string]time.Time
+	hasState bool
+}
+
+// NewWatcher creates a watcher, restoring previously seen pull requests from statePath.
+func NewWatcher(repositories []RepositoryConfig, sinks []Sink, statePath string) (*Watcher, error) {
+	w := &Watcher{
+		repositories: repositories,
+		sinks:        sinks,
+		statePath:    statePath,
+		scan:         ScanRepositories,
+		seen:         make(map[string]map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch state: %w", err)
+	}
+
+	seen, err := parseState(data)
+	if err != nil {
+		// Losing the state only means the next poll records the current matches again
+		fmt.Fprintf(os.Stderr, "Warning: discarding watch state %s: %v\n", statePath, err)
+		return w, nil
+	}
+	w.seen = seen
+	w.hasState = true
+
+	return w, nil
+}
+
+// stateVersion is the version of the watch state file. Version 1 mapped "owner/repo#123"
+// keys to the time each pull request was first seen, and the unversioned state written
+// before version 2 had the same shape as its seen field.
+const stateVersion = 2
+
+type watchState struct {
+	Version int                             `json:"version"`
+	Seen    map[string]map[string]time.Time `json:"seen"`
+}
+
+// parseState reads the seen pull requests from a state file, migrating older versions.
+func parseState(data []byte) (map[string]map[string]time.Time, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if _, ok := fields["version"]; ok {
+		var state watchState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, err
+		}
+		if state.Version != stateVersion {
+			return nil, fmt.Errorf("unsupported version %d", state.Version)
+		}
+		if state.Seen == nil {
+			state.Seen = make(map[string]map[string]time.Time)
+		}
+		return state.Seen, nil
+	}
+
+	seen := make(map[string]map[string]time.Time)
+	if err := json.Unmarshal(data, &seen); err == nil {
+		return seen, nil
+	}
+
+	var legacy map[string]time.Time
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("unknown format: %w", err)
+	}
+
+	// The failed attempt above may have filled in part of seen
+	seen = make(map[string]map[string]time.Time)
+	for key, firstSeen := range legacy {
+		i := strings.LastIndex(key, "#")
+		if i < 0 {
+			continue
+		}
+
+		name, ref := key[:i], key[i:]
+		if seen[name] == nil {
+			seen[name] = make(map[string]time.Time)
+		}
+		seen[name][ref] = firstSeen
+	}
+
+	return seen, nil
+}
+
+// DefaultStatePath returns the file used to remember which pull requests were already notified.
+func DefaultStatePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "daiv", "relevantprs-watch.json"), nil
+}
+
+// Run polls the repositories every interval until the context is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx); err != nil {
+			log.Printf("Error polling repositories: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans all repositories once and notifies the sinks about newly matching pull requests.
+func (w *Watcher) Poll(ctx context.Context) error {
+	results := w.scan(ctx, w.repositories)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	notify := w.hasState || w.NotifyExisting
+
+	// Repositories that failed to scan are not in the results and keep their previous
+	// state, so that a transient error does not cause every pull request to be notified again.
+	for _, result := range results {
+		name := result.Repository.FullName()
+		previous := w.seen[name]
+		current := make(map[string]time.Time)
+
+		for _, pr := range result.PullRequests {
+			if firstSeen, ok := previous[pr.Ref()]; ok {
+				current[pr.Ref()] = firstSeen
+				continue
+			}
+			current[pr.Ref()] = time.Now()
+
+			if notify {
+				w.notify(NewNotification(result.Repository, pr))
+			}
+		}
+
+		w.seen[name] = current
+	}
+
+	// Forget the repositories that were removed from the config
+	configured := make(map[string]bool, len(w.repositories))
+	for _, repository := range w.repositories {
+		configured[repository.FullName()] = true
+	}
+	for name := range w.seen {
+		if !configured[name] {
+			delete(w.seen, name)
+		}
+	}
+
+	w.hasState = true
+
+	return w.saveState()
+}
+
+func (w *Watcher) notify(notification Notification) {
+	for _, sink := range w.sinks {
+		if err := sink.Notify(notification); err != nil {
+			log.Printf("Error sending notification for %s %s: %v", notification.Repository, notification.Ref, err)
+		}
+	}
+}
+
+func (w *Watcher) saveState() error {
+	data, err := json.MarshalIndent(watchState{Version: stateVersion, Seen: w.seen}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watch state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create watch state directory: %w", err)
+	}
+
+	return os.WriteFile(w.statePath, data, 0644)
+}
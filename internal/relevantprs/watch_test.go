@@ -0,0 +1,235 @@
+package relevantprs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewWatcherRestoresState(t *testing.T) {
+	firstSeen := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		state     string
+		want      map[string]map[string]time.Time
+		wantState bool
+	}{
+		{
+			name:      "current version",
+			state:     `{"version": 2, "seen": {"acme/api": {"#12": "2025-03-01T09:30:00Z", "feature/x": "2025-03-01T09:30:00Z"}}}`,
+			want:      map[string]map[string]time.Time{"acme/api": {"#12": firstSeen, "feature/x": firstSeen}},
+			wantState: true,
+		},
+		{
+			name:      "unversioned nested state",
+			state:     `{"acme/api": {"#12": "2025-03-01T09:30:00Z"}}`,
+			want:      map[string]map[string]time.Time{"acme/api": {"#12": firstSeen}},
+			wantState: true,
+		},
+		{
+			name:  "version 1 keys",
+			state: `{"acme/api#12": "2025-03-01T09:30:00Z", "acme/api#13": "2025-03-01T09:30:00Z", "group/sub/web#7": "2025-03-01T09:30:00Z"}`,
+			want: map[string]map[string]time.Time{
+				"acme/api":      {"#12": firstSeen, "#13": firstSeen},
+				"group/sub/web": {"#7": firstSeen},
+			},
+			wantState: true,
+		},
+		{
+			name:  "unsupported version",
+			state: `{"version": 3, "seen": {}}`,
+			want:  map[string]map[string]time.Time{},
+		},
+		{
+			name:  "corrupt state",
+			state: `{"acme/api": `,
+			want:  map[string]map[string]time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "watch.json")
+			if err := os.WriteFile(statePath, []byte(test.state), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			watcher, err := NewWatcher(nil, nil, statePath)
+			if err != nil {
+				t.Fatalf("NewWatcher: %v", err)
+			}
+
+			if watcher.hasState != test.wantState {
+				t.Errorf("hasState = %v, want %v", watcher.hasState, test.wantState)
+			}
+			if !equalSeen(watcher.seen, test.want) {
+				t.Errorf("seen = %v, want %v", watcher.seen, test.want)
+			}
+		})
+	}
+}
+
+func TestWatcherStateRoundTrip(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	firstSeen := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	watcher := &Watcher{
+		statePath: statePath,
+		seen:      map[string]map[string]time.Time{"acme/api": {"#12": firstSeen}},
+	}
+	if err := watcher.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewWatcher(nil, nil, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.hasState || !equalSeen(restored.seen, watcher.seen) {
+		t.Errorf("restored seen = %v, want %v", restored.seen, watcher.seen)
+	}
+}
+
+// recordingSink collects the refs it is notified about.
+type recordingSink struct {
+	refs []string
+}
+
+func (s *recordingSink) Notify(notification Notification) error {
+	s.refs = append(s.refs, notification.Repository+notification.Ref)
+	return nil
+}
+
+func TestWatcherPoll(t *testing.T) {
+	api := RepositoryConfig{Owner: "acme", Repo: "api"}
+	web := RepositoryConfig{Owner: "acme", Repo: "web"}
+	pr := func(number int) PullRequestMatch {
+		return PullRequestMatch{Change: Change{Number: number, Title: "Change"}}
+	}
+
+	tests := []struct {
+		name           string
+		notifyExisting bool
+		// polls are the scan results of each poll, a repository missing from them failed to scan
+		polls [][]RepositoryResult
+		want  [][]string
+	}{
+		{
+			name: "first poll only records the current matches",
+			polls: [][]RepositoryResult{
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}},
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1), pr(2)}}},
+			},
+			want: [][]string{nil, {"acme/api#2"}},
+		},
+		{
+			name:           "notify existing matches",
+			notifyExisting: true,
+			polls: [][]RepositoryResult{
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}, {Repository: web, PullRequests: []PullRequestMatch{pr(5)}}},
+			},
+			want: [][]string{{"acme/api#1", "acme/web#5"}},
+		},
+		{
+			name: "failed scans keep their state",
+			polls: [][]RepositoryResult{
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}},
+				{},
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}},
+			},
+			want: [][]string{nil, nil, nil},
+		},
+		{
+			name: "pull requests that match again are notified again",
+			polls: [][]RepositoryResult{
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}},
+				{{Repository: api}},
+				{{Repository: api, PullRequests: []PullRequestMatch{pr(1)}}},
+			},
+			want: [][]string{nil, nil, {"acme/api#1"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			watcher, err := NewWatcher([]RepositoryConfig{api, web}, []Sink{sink}, filepath.Join(t.TempDir(), "watch.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			watcher.NotifyExisting = test.notifyExisting
+
+			for i, results := range test.polls {
+				watcher.scan = func(ctx context.Context, repositories []RepositoryConfig) []RepositoryResult {
+					return results
+				}
+				sink.refs = nil
+
+				if err := watcher.Poll(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(sink.refs, test.want[i]) {
+					t.Errorf("poll %d notified %v, want %v", i+1, sink.refs, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWatcherPollForgetsRemovedRepositories(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "watch.json")
+	firstSeen := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	api := RepositoryConfig{Owner: "acme", Repo: "api"}
+
+	watcher := &Watcher{
+		statePath: statePath,
+		seen: map[string]map[string]time.Time{
+			"acme/api": {"#1": firstSeen},
+			"acme/old": {"#2": firstSeen},
+		},
+	}
+	if err := watcher.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := NewWatcher([]RepositoryConfig{api}, nil, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher.scan = func(ctx context.Context, repositories []RepositoryConfig) []RepositoryResult {
+		return []RepositoryResult{{Repository: api, PullRequests: []PullRequestMatch{{Change: Change{Number: 1}}}}}
+	}
+	if err := watcher.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewWatcher(nil, nil, statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]time.Time{"acme/api": {"#1": firstSeen}}
+	if !equalSeen(restored.seen, want) {
+		t.Errorf("saved seen = %v, want %v", restored.seen, want)
+	}
+}
+
+func equalSeen(a, b map[string]map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, refs := range a {
+		if len(refs) != len(b[name]) {
+			return false
+		}
+		for ref, firstSeen := range refs {
+			if other, ok := b[name][ref]; !ok || !other.Equal(firstSeen) {
+				return false
+			}
+		}
+	}
+	return true
+}